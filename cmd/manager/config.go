@@ -19,15 +19,21 @@ import (
 	"io/ioutil"
 	"os"
 	"sort"
+	"time"
 
 	"github.com/ghodss/yaml"
 
 	"k8s.io/client-go/pkg/api"
 
 	"github.com/golang/protobuf/proto"
+	multierror "github.com/hashicorp/go-multierror"
 	"github.com/spf13/cobra"
 
 	"istio.io/manager/model"
+	proxyconfig "istio.io/manager/model/proxy/alphav1/config"
+	"istio.io/manager/platform/file"
+	"istio.io/manager/platform/history"
+	"istio.io/manager/platform/kube"
 )
 
 var (
@@ -45,44 +51,142 @@ var (
 		},
 	}
 
-	putCmd = &cobra.Command{
-		Use:   "put [kind] [name]",
-		Short: "Store a configuration object from standard input YAML",
+	createCmd = &cobra.Command{
+		Use:   "create [kind] [name]",
+		Short: "Create a configuration object from standard input YAML",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 2 {
 				return fmt.Errorf("Provide kind and name")
 			}
-			kind, ok := model.IstioConfig[args[0]]
-			if !ok {
-				return fmt.Errorf("Missing kind %s", args[0])
+			v, err := readInput(args[0])
+			if err != nil {
+				return err
 			}
 			if flags.namespace == "" {
 				flags.namespace = api.NamespaceDefault
 			}
 
-			// read stdin
-			bytes, err := ioutil.ReadAll(os.Stdin)
-			if err != nil {
-				return fmt.Errorf("Cannot read input: %v", err)
+			key := model.Key{
+				Kind:      args[0],
+				Name:      args[1],
+				Namespace: flags.namespace,
+			}
+			if err = validateSemantics([]model.Config{{Key: key, Content: v}}); err != nil {
+				return err
 			}
 
-			out, err := yaml.YAMLToJSON(bytes)
+			registry, err := configRegistry()
 			if err != nil {
-				return fmt.Errorf("Cannot read YAML input: %v", err)
+				return err
 			}
+			revision, err := registry.Post(key, v)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created %s %s at revision %s\n", args[0], args[1], revision)
+			return nil
+		},
+	}
 
-			v, err := kind.FromJSON(string(out))
+	putCmd = &cobra.Command{
+		Use:   "put [kind] [name]",
+		Short: "Update a configuration object from standard input YAML",
+		Long: `Update a configuration object from standard input YAML.
+The update fails if the object has been modified since the revision given by
+the --revision flag. The --force flag overwrites the latest revision instead.
+The object must exist: put no longer creates missing objects, use create.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return fmt.Errorf("Provide kind and name")
+			}
+			v, err := readInput(args[0])
 			if err != nil {
-				return fmt.Errorf("Cannot parse proto message: %v", err)
+				return err
+			}
+			if flags.namespace == "" {
+				flags.namespace = api.NamespaceDefault
 			}
 
-			err = flags.client.Put(model.Key{
+			key := model.Key{
 				Kind:      args[0],
 				Name:      args[1],
 				Namespace: flags.namespace,
-			}, v)
+			}
+			revision := flags.revision
+			switch {
+			case revision != "" && flags.force:
+				return fmt.Errorf("Provide either --revision or --force")
+			case revision == "" && !flags.force:
+				return fmt.Errorf("Provide the expected revision with --revision, or overwrite with --force")
+			case flags.force:
+				_, exists, current := flags.client.Get(key)
+				if !exists {
+					return &model.NotFoundError{Key: key}
+				}
+				revision = current
+			}
+			if err = validateSemantics([]model.Config{{Key: key, Content: v}}); err != nil {
+				return err
+			}
 
-			return err
+			registry, err := configRegistry()
+			if err != nil {
+				return err
+			}
+			revision, err = registry.Put(key, v, revision)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Updated %s %s to revision %s\n", args[0], args[1], revision)
+			return nil
+		},
+	}
+
+	applyCmd = &cobra.Command{
+		Use:   "apply",
+		Short: "Apply a batch of configuration objects from a YAML file",
+		Long: `Apply a batch of configuration objects from a multi-document YAML file.
+Each document declares the kind, name, namespace, and spec of an object.
+All objects are validated before any change is made, and the objects are
+written in the order of declaration. If a write fails, the objects changed
+by the batch are restored to their previous versions.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.file == "" {
+				return fmt.Errorf("Provide a file with -f, or - for standard input")
+			}
+			if flags.namespace == "" {
+				flags.namespace = api.NamespaceDefault
+			}
+
+			var bytes []byte
+			var err error
+			if flags.file == "-" {
+				bytes, err = ioutil.ReadAll(os.Stdin)
+			} else {
+				bytes, err = ioutil.ReadFile(flags.file)
+			}
+			if err != nil {
+				return fmt.Errorf("Cannot read input: %v", err)
+			}
+
+			configs, err := file.ParseConfig(model.IstioConfig, flags.namespace, bytes)
+			if err != nil {
+				return err
+			}
+			if err = validateSemantics(configs); err != nil {
+				return err
+			}
+			registry, err := configRegistry()
+			if err != nil {
+				return err
+			}
+			if err = model.Apply(registry, model.IstioConfig, configs); err != nil {
+				return err
+			}
+			for _, config := range configs {
+				fmt.Printf("Applied %v\n", config.Key)
+			}
+			return nil
 		},
 	}
 
@@ -96,7 +200,7 @@ var (
 			if flags.namespace == "" {
 				flags.namespace = api.NamespaceDefault
 			}
-			item, exists := flags.client.Get(model.Key{
+			item, exists, revision := flags.client.Get(model.Key{
 				Kind:      args[0],
 				Name:      args[1],
 				Namespace: flags.namespace,
@@ -104,6 +208,7 @@ var (
 			if !exists {
 				return fmt.Errorf("Does not exist")
 			}
+			fmt.Fprintf(os.Stderr, "revision: %s\n", revision)
 			print(args[0], item)
 			return nil
 		},
@@ -118,11 +223,12 @@ var (
 				if err != nil {
 					fmt.Printf("Error listing %s: %v\n", kind, err)
 				} else {
-					for key, item := range list {
-						fmt.Printf("kind: %s\n", key.Kind)
-						fmt.Printf("name: %s\n", key.Name)
-						fmt.Printf("namespace: %s\n", key.Namespace)
-						print(key.Kind, item)
+					for _, item := range list {
+						fmt.Printf("kind: %s\n", item.Kind)
+						fmt.Printf("name: %s\n", item.Name)
+						fmt.Printf("namespace: %s\n", item.Namespace)
+						fmt.Printf("revision: %s\n", item.Revision)
+						print(item.Kind, item.Content)
 						fmt.Println("---")
 					}
 				}
@@ -141,21 +247,253 @@ var (
 			if flags.namespace == "" {
 				flags.namespace = api.NamespaceDefault
 			}
-			err := flags.client.Delete(model.Key{
+			registry, err := configRegistry()
+			if err != nil {
+				return err
+			}
+			return registry.Delete(model.Key{
 				Kind:      args[0],
 				Name:      args[1],
 				Namespace: flags.namespace,
 			})
+		},
+	}
+
+	lintCmd = &cobra.Command{
+		Use:   "lint",
+		Short: "Report conflicting route rules",
+		Long: `Report route rules for the same destination that have the same precedence,
+that are unreachable because a rule applied before them matches every request
+they match, or that partially overlap with another rule. Rules with the same
+precedence are applied in the order of namespace and name.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registry := &model.IstioRegistry{ConfigRegistry: flags.client}
+			conflicts := registry.AnalyzeRouteRules(flags.namespace)
+			for _, conflict := range conflicts {
+				fmt.Printf("%s: %v\n", conflict.Type, conflict)
+			}
+			if len(conflicts) > 0 {
+				return fmt.Errorf("Found %d route rule conflicts", len(conflicts))
+			}
+			return nil
+		},
+	}
+
+	migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Rewrite configuration objects in the current schema version",
+		Long: `Rewrite the configuration objects stored in an older schema version in the
+current schema version of their kind. Objects are read in any supported version
+and upgraded, so the migration only changes the stored representation. With the
+--dry-run flag, the objects to migrate are reported without any change.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registry, err := configRegistry()
+			if err != nil {
+				return err
+			}
+			migrations, err := model.Migrate(registry, model.IstioConfig, flags.namespace, flags.dryRun)
+			for _, migration := range migrations {
+				if flags.dryRun {
+					fmt.Printf("Would migrate %v from %s to %s\n", migration.Key, migration.From, migration.To)
+				} else {
+					fmt.Printf("Migrated %v from %s to %s\n", migration.Key, migration.From, migration.To)
+				}
+			}
+			if err == nil && len(migrations) == 0 {
+				fmt.Println("All objects are in the current schema version")
+			}
 			return err
 		},
 	}
+
+	historyCmd = &cobra.Command{
+		Use:   "history [kind] [name]",
+		Short: "List the recorded changes of a configuration object",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return fmt.Errorf("Provide kind and name")
+			}
+			if flags.namespace == "" {
+				flags.namespace = api.NamespaceDefault
+			}
+			registry, err := historyRegistry()
+			if err != nil {
+				return err
+			}
+			for _, entry := range registry.History(model.Key{
+				Kind:      args[0],
+				Name:      args[1],
+				Namespace: flags.namespace,
+			}) {
+				fmt.Printf("time: %s\n", entry.Time.Format(time.RFC3339))
+				fmt.Printf("actor: %s\n", entry.Actor)
+				if entry.Current != nil {
+					print(args[0], entry.Current)
+				} else {
+					fmt.Println("deleted: true")
+				}
+				fmt.Println("---")
+			}
+			return nil
+		},
+	}
+
+	restoreCmd = &cobra.Command{
+		Use:   "restore [kind name]",
+		Short: "Restore a configuration object or a namespace to a point in time",
+		Long: `Restore a configuration object to its state at the time given by the --time
+flag in RFC 3339 format. Without a kind and a name, all objects with recorded
+changes in the namespace are restored.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 && len(args) != 2 {
+				return fmt.Errorf("Provide kind and name, or nothing to restore the namespace")
+			}
+			t, err := time.Parse(time.RFC3339, flags.restoreTime)
+			if err != nil {
+				return fmt.Errorf("Cannot parse the time: %v", err)
+			}
+			if flags.namespace == "" {
+				flags.namespace = api.NamespaceDefault
+			}
+			registry, err := historyRegistry()
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				return registry.RestoreNamespace(flags.namespace, t)
+			}
+			return registry.Restore(model.Key{
+				Kind:      args[0],
+				Name:      args[1],
+				Namespace: flags.namespace,
+			}, t)
+		},
+	}
 )
 
 func init() {
+	putCmd.PersistentFlags().StringVar(&flags.revision, "revision", "",
+		"Expected revision of the object to update")
+	putCmd.PersistentFlags().BoolVar(&flags.force, "force", false,
+		"Overwrite the latest revision of the object regardless of concurrent updates")
+
+	configCmd.PersistentFlags().StringVar(&flags.history, "history", "",
+		"Record configuration changes in a local JSON file")
+	configCmd.PersistentFlags().StringVar(&flags.actor, "actor", os.Getenv("USER"),
+		"Actor recorded in the configuration history")
+	restoreCmd.PersistentFlags().StringVar(&flags.restoreTime, "time", "",
+		"Point in time to restore in RFC 3339 format")
+
+	migrateCmd.PersistentFlags().BoolVar(&flags.dryRun, "dry-run", false,
+		"Report the objects to migrate without changing them")
+
+	applyCmd.PersistentFlags().StringVarP(&flags.file, "file", "f", "",
+		"Input YAML file with configuration objects, or - for standard input")
+
+	configCmd.AddCommand(createCmd)
 	configCmd.AddCommand(putCmd)
+	configCmd.AddCommand(applyCmd)
 	configCmd.AddCommand(getCmd)
 	configCmd.AddCommand(listCmd)
 	configCmd.AddCommand(deleteCmd)
+	configCmd.AddCommand(lintCmd)
+	configCmd.AddCommand(migrateCmd)
+	configCmd.AddCommand(historyCmd)
+	configCmd.AddCommand(restoreCmd)
+}
+
+// configRegistry returns the registry for configuration changes, which
+// records the history if a history file is provided
+func configRegistry() (model.ConfigRegistry, error) {
+	if flags.history == "" {
+		return flags.client, nil
+	}
+	return historyRegistry()
+}
+
+func historyRegistry() (*history.Registry, error) {
+	if flags.history == "" {
+		return nil, fmt.Errorf("Provide a history file with --history")
+	}
+	return history.NewRegistry(flags.client, model.IstioConfig, flags.history, flags.actor)
+}
+
+// validateSemantics checks routing rules against the service registries, the
+// stored rules, and the other rules of the batch. Warnings are printed, and
+// errors prevent any change.
+func validateSemantics(configs []model.Config) error {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	var discovery model.ServiceDiscovery
+	var errs error
+	for _, config := range configs {
+		if config.Kind != model.RouteRule {
+			continue
+		}
+		rule, ok := config.Content.(*proxyconfig.RouteRule)
+		if !ok {
+			continue
+		}
+		if discovery == nil {
+			var err error
+			if discovery, err = syncDiscovery(stop); err != nil {
+				return err
+			}
+		}
+		warnings, err := model.ValidateRouteRuleSemantics(config.Key, rule, discovery, flags.client, configs)
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %v: %s\n", config.Key, warning)
+		}
+		if err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, config.Key.String()+":"))
+		}
+	}
+	return errs
+}
+
+// syncDiscovery starts the service registries and waits for the initial
+// synchronization with Kubernetes
+func syncDiscovery(stop chan struct{}) (model.ServiceDiscovery, error) {
+	controller := kube.NewController(flags.client, "", resyncPeriod)
+	discovery, err := makeDiscovery(controller)
+	if err != nil {
+		return nil, err
+	}
+	go discovery.Run(stop)
+	deadline := time.Now().Add(syncTimeout)
+	for !controller.HasSynced() {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("Timed out waiting for the service registry")
+		}
+		time.Sleep(resyncPeriod)
+	}
+	return discovery, nil
+}
+
+// readInput reads a configuration object of a kind from standard input YAML
+func readInput(kind string) (proto.Message, error) {
+	schema, ok := model.IstioConfig[kind]
+	if !ok {
+		return nil, fmt.Errorf("Missing kind %s", kind)
+	}
+
+	// read stdin
+	bytes, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read input: %v", err)
+	}
+
+	out, err := yaml.YAMLToJSON(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read YAML input: %v", err)
+	}
+
+	v, err := schema.FromJSON(string(out))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot parse proto message: %v", err)
+	}
+	return v, nil
 }
 
 func print(kind string, item proto.Message) {
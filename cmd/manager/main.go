@@ -23,6 +23,9 @@ import (
 	"time"
 
 	"istio.io/manager/model"
+	"istio.io/manager/platform/aggregate"
+	"istio.io/manager/platform/external"
+	"istio.io/manager/platform/file"
 	"istio.io/manager/platform/kube"
 	"istio.io/manager/proxy/envoy"
 
@@ -33,12 +36,21 @@ import (
 )
 
 type args struct {
-	kubeconfig string
-	namespace  string
-	client     *kube.Client
-	server     serverArgs
-	proxy      envoy.MeshConfig
-	identity   envoy.ProxyNode
+	kubeconfig  string
+	namespace   string
+	servicesDir string
+	registries  []string
+	revision    string
+	force       bool
+	file        string
+	history     string
+	actor       string
+	restoreTime string
+	dryRun      bool
+	client      *kube.Client
+	server      serverArgs
+	proxy       envoy.MeshConfig
+	identity    envoy.ProxyNode
 }
 
 type serverArgs struct {
@@ -46,7 +58,13 @@ type serverArgs struct {
 }
 
 const (
-	resyncPeriod = 100 * time.Millisecond
+	resyncPeriod   = 100 * time.Millisecond
+	servicesPeriod = 5 * time.Second
+	syncTimeout    = 30 * time.Second
+
+	kubernetesRegistry = "kubernetes"
+	fileRegistry       = "file"
+	externalRegistry   = "external"
 )
 
 var (
@@ -78,9 +96,13 @@ Istio Manager provides management plane functionality to the Istio proxy mesh an
 		Short: "Start Istio Manager discovery service",
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			controller := kube.NewController(flags.client, flags.namespace, resyncPeriod)
-			sds := envoy.NewDiscoveryService(controller, flags.server.sdsPort)
+			discovery, err := makeDiscovery(controller)
+			if err != nil {
+				return
+			}
+			sds := envoy.NewDiscoveryService(discovery, flags.server.sdsPort)
 			stop := make(chan struct{})
-			go controller.Run(stop)
+			go discovery.Run(stop)
 			go sds.Run()
 			waitSignal(stop)
 			return
@@ -98,13 +120,18 @@ Istio Manager provides management plane functionality to the Istio proxy mesh an
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			setFlagsFromEnv()
 			controller := kube.NewController(flags.client, flags.namespace, resyncPeriod)
-			_, err = envoy.NewWatcher(controller, controller, &model.IstioRegistry{ConfigRegistry: controller},
+			discovery, err := makeDiscovery(controller)
+			if err != nil {
+				return
+			}
+			watcher, err := envoy.NewWatcher(discovery, discovery, &model.IstioRegistry{ConfigRegistry: controller},
 				&flags.proxy, &flags.identity)
 			if err != nil {
 				return
 			}
 			stop := make(chan struct{})
-			go controller.Run(stop)
+			go discovery.Run(stop)
+			go watcher.Run(stop)
 			waitSignal(stop)
 			return
 		},
@@ -116,13 +143,18 @@ Istio Manager provides management plane functionality to the Istio proxy mesh an
 		RunE: func(cmd *cobra.Command, args []string) error {
 			setFlagsFromEnv()
 			controller := kube.NewController(flags.client, flags.namespace, resyncPeriod)
-			_, err := envoy.NewIngressWatcher(controller, controller, &model.IstioRegistry{ConfigRegistry: controller},
+			discovery, err := makeDiscovery(controller)
+			if err != nil {
+				return err
+			}
+			watcher, err := envoy.NewIngressWatcher(discovery, discovery, &model.IstioRegistry{ConfigRegistry: controller},
 				&flags.proxy, &flags.identity)
 			if err != nil {
 				return err
 			}
 			stop := make(chan struct{})
-			go controller.Run(stop)
+			go discovery.Run(stop)
+			go watcher.Run(stop)
 			waitSignal(stop)
 			return nil
 		},
@@ -132,8 +164,20 @@ Istio Manager provides management plane functionality to the Istio proxy mesh an
 		Use:   "egress",
 		Short: "Istio Proxy external service agent",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: implement this method
+			setFlagsFromEnv()
+			controller := kube.NewController(flags.client, flags.namespace, resyncPeriod)
+			discovery, err := makeDiscovery(controller)
+			if err != nil {
+				return err
+			}
+			watcher, err := envoy.NewEgressWatcher(discovery, discovery, &model.IstioRegistry{ConfigRegistry: controller},
+				&flags.proxy, &flags.identity)
+			if err != nil {
+				return err
+			}
 			stop := make(chan struct{})
+			go discovery.Run(stop)
+			go watcher.Run(stop)
 			waitSignal(stop)
 			return nil
 		},
@@ -145,6 +189,11 @@ func init() {
 		"Use a Kubernetes configuration file instead of in-cluster configuration")
 	rootCmd.PersistentFlags().StringVarP(&flags.namespace, "namespace", "n", "",
 		"Select the specified namespace for the Kubernetes controller to watch instead of all namespaces")
+	rootCmd.PersistentFlags().StringVar(&flags.servicesDir, "services_dir", "",
+		"Declare additional services and instances from the YAML files in a directory")
+	rootCmd.PersistentFlags().StringSliceVar(&flags.registries, "registry_priority",
+		[]string{kubernetesRegistry, fileRegistry},
+		"Service registries in the order of priority for resolving conflicting service declarations")
 	rootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
 
 	discoveryCmd.PersistentFlags().IntVarP(&flags.server.sdsPort, "port", "p", 8080,
@@ -166,6 +215,10 @@ func init() {
 		"Envoy config root location")
 	proxyCmd.PersistentFlags().StringVarP(&flags.proxy.MixerAddress, "mixer", "m", "",
 		"Mixer DNS address (or empty to disable Mixer)")
+	proxyCmd.PersistentFlags().DurationVar(&flags.proxy.ReloadQuietPeriod, "reload_quiet_period", 100*time.Millisecond,
+		"Period without registry events after which Envoy is reloaded")
+	proxyCmd.PersistentFlags().DurationVar(&flags.proxy.ReloadMaxDelay, "reload_max_delay", 1*time.Second,
+		"Maximum delay of an Envoy reload after a registry event")
 
 	rootCmd.AddCommand(discoveryCmd)
 	rootCmd.AddCommand(configCmd)
@@ -190,6 +243,41 @@ func waitSignal(stop chan struct{}) {
 	glog.Flush()
 }
 
+// makeDiscovery composes the Kubernetes service registry with the optional
+// file service registry in the order of the registry priority flag, followed
+// by the external services declared in the configuration
+func makeDiscovery(controller *kube.Controller) (*aggregate.Controller, error) {
+	registries := map[string]aggregate.Registry{
+		kubernetesRegistry: {Name: kubernetesRegistry, ServiceDiscovery: controller, Controller: controller},
+	}
+	if flags.servicesDir != "" {
+		services := file.NewServiceController(flags.servicesDir, servicesPeriod)
+		registries[fileRegistry] = aggregate.Registry{Name: fileRegistry, ServiceDiscovery: services, Controller: services}
+	}
+
+	ordered := make([]aggregate.Registry, 0, len(registries))
+	for _, name := range flags.registries {
+		switch name {
+		case kubernetesRegistry, fileRegistry:
+		default:
+			return nil, fmt.Errorf("Unknown service registry %q", name)
+		}
+		if registry, ok := registries[name]; ok {
+			ordered = append(ordered, registry)
+			delete(registries, name)
+		}
+	}
+	for name := range registries {
+		return nil, fmt.Errorf("Missing service registry %q in the priority list", name)
+	}
+
+	// external services come last, so that they never shadow the services in the mesh
+	externals := external.NewController(controller, controller)
+	ordered = append(ordered,
+		aggregate.Registry{Name: externalRegistry, ServiceDiscovery: externals, Controller: externals})
+	return aggregate.NewController(ordered...), nil
+}
+
 // setFlagsFromEnv sets default values for flags that are not specified
 func setFlagsFromEnv() {
 	if flags.identity.IP == "" {
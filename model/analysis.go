@@ -0,0 +1,231 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+
+	proxyconfig "istio.io/manager/model/proxy/alphav1/config"
+)
+
+// ConflictType classifies the interaction between two route rules
+type ConflictType string
+
+const (
+	// ConflictTie is reported for rules with the same destination and precedence,
+	// which are applied in the order of namespace and name
+	ConflictTie ConflictType = "tie"
+	// ConflictShadowed is reported for a rule that is unreachable because a rule
+	// applied before it matches every request that it matches
+	ConflictShadowed ConflictType = "shadowed"
+	// ConflictOverlap is reported for rules that match some of the same requests
+	// without one rule containing the other
+	ConflictOverlap ConflictType = "overlap"
+)
+
+// Conflict describes a problem between two route rules for the same destination
+type Conflict struct {
+	Type ConflictType
+	// Rule is the rule applied after the other rule
+	Rule Key
+	// Other is the rule applied first
+	Other Key
+	// Destination of both rules
+	Destination string
+}
+
+func (c Conflict) String() string {
+	switch c.Type {
+	case ConflictTie:
+		return fmt.Sprintf("%v has the same precedence as %v for destination %q", c.Rule, c.Other, c.Destination)
+	case ConflictShadowed:
+		return fmt.Sprintf("%v is shadowed by %v for destination %q", c.Rule, c.Other, c.Destination)
+	default:
+		return fmt.Sprintf("%v overlaps with %v for destination %q", c.Rule, c.Other, c.Destination)
+	}
+}
+
+// AnalyzeRouteRules reports the conflicts between route rules in a namespace
+// (or all rules if namespace is "")
+func (i *IstioRegistry) AnalyzeRouteRules(namespace string) []Conflict {
+	rs, err := i.List(RouteRule, namespace)
+	if err != nil {
+		glog.V(2).Infof("AnalyzeRouteRules => %v", err)
+	}
+	return AnalyzeRouteRules(rs)
+}
+
+// AnalyzeRouteRules reports the conflicts between pairs of route rules with the
+// same destination, in the order of application of the rules
+func AnalyzeRouteRules(configs []Config) []Conflict {
+	destinations := make(map[string][]Config)
+	for _, config := range configs {
+		if rule, ok := config.Content.(*proxyconfig.RouteRule); ok {
+			destinations[rule.Destination] = append(destinations[rule.Destination], config)
+		}
+	}
+	hostnames := make([]string, 0, len(destinations))
+	for hostname := range destinations {
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+
+	out := make([]Conflict, 0)
+	for _, hostname := range hostnames {
+		rules := destinations[hostname]
+		sort.Sort(RouteRulePrecedence(rules))
+		for j := range rules {
+			rule := rules[j].Content.(*proxyconfig.RouteRule)
+			for k := 0; k < j; k++ {
+				other := rules[k].Content.(*proxyconfig.RouteRule)
+				conflict := Conflict{Rule: rules[j].Key, Other: rules[k].Key, Destination: hostname}
+				if rule.Precedence == other.Precedence {
+					conflict.Type = ConflictTie
+					out = append(out, conflict)
+				}
+				switch {
+				case covers(other.Match, rule.Match):
+					conflict.Type = ConflictShadowed
+					out = append(out, conflict)
+				case !covers(rule.Match, other.Match) && !disjoint(rule.Match, other.Match):
+					conflict.Type = ConflictOverlap
+					out = append(out, conflict)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// covers is true if the condition a matches every request matched by the condition b
+func covers(a, b *proxyconfig.MatchCondition) bool {
+	a, b = matchOrEmpty(a), matchOrEmpty(b)
+	if a.Source != "" && a.Source != b.Source {
+		return false
+	}
+	for k, v := range a.SourceTags {
+		w, ok := b.SourceTags[k]
+		switch {
+		case !ok:
+			return false
+		case isSelector(w):
+			// compare requirements literally
+			if canonicalTagValue(v) != canonicalTagValue(w) {
+				return false
+			}
+		case !matchTag(v, w, true):
+			return false
+		}
+	}
+	if (a.Tcp != nil && !proto.Equal(a.Tcp, b.Tcp)) || (a.Udp != nil && !proto.Equal(a.Udp, b.Udp)) {
+		return false
+	}
+	bHTTP := httpMatches(b)
+	for name, match := range httpMatches(a) {
+		if !coversString(match, bHTTP[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// disjoint is true if no request can be matched by both conditions
+func disjoint(a, b *proxyconfig.MatchCondition) bool {
+	a, b = matchOrEmpty(a), matchOrEmpty(b)
+	if a.Source != "" && b.Source != "" && a.Source != b.Source {
+		return true
+	}
+	for k, v := range a.SourceTags {
+		w, ok := b.SourceTags[k]
+		switch {
+		case !ok || (isSelector(v) && isSelector(w)):
+		case isSelector(w):
+			if !matchTag(w, v, true) {
+				return true
+			}
+		case !matchTag(v, w, true):
+			return true
+		}
+	}
+	bHTTP := httpMatches(b)
+	for name, match := range httpMatches(a) {
+		if other, ok := bHTTP[name]; ok && disjointString(match, other) {
+			return true
+		}
+	}
+	return false
+}
+
+// httpMatches returns the HTTP match conditions with case-insensitive keys
+func httpMatches(match *proxyconfig.MatchCondition) map[string]*proxyconfig.StringMatch {
+	out := make(map[string]*proxyconfig.StringMatch, len(match.Http))
+	for name, m := range match.Http {
+		out[strings.ToLower(name)] = m
+	}
+	return out
+}
+
+// coversString is true if the string match a accepts every value accepted by b.
+// Regular expressions only cover identical expressions.
+func coversString(a, b *proxyconfig.StringMatch) bool {
+	if b == nil {
+		return false
+	}
+	switch ma := a.GetMatchType().(type) {
+	case *proxyconfig.StringMatch_Exact:
+		mb, ok := b.MatchType.(*proxyconfig.StringMatch_Exact)
+		return ok && ma.Exact == mb.Exact
+	case *proxyconfig.StringMatch_Prefix:
+		switch mb := b.MatchType.(type) {
+		case *proxyconfig.StringMatch_Exact:
+			return strings.HasPrefix(mb.Exact, ma.Prefix)
+		case *proxyconfig.StringMatch_Prefix:
+			return strings.HasPrefix(mb.Prefix, ma.Prefix)
+		}
+		return false
+	case *proxyconfig.StringMatch_Regex:
+		mb, ok := b.MatchType.(*proxyconfig.StringMatch_Regex)
+		return ok && ma.Regex == mb.Regex
+	default:
+		return false
+	}
+}
+
+// disjointString is true if no value is accepted by both string matches.
+// Regular expressions are assumed to intersect with any match.
+func disjointString(a, b *proxyconfig.StringMatch) bool {
+	switch ma := a.GetMatchType().(type) {
+	case *proxyconfig.StringMatch_Exact:
+		switch mb := b.GetMatchType().(type) {
+		case *proxyconfig.StringMatch_Exact:
+			return ma.Exact != mb.Exact
+		case *proxyconfig.StringMatch_Prefix:
+			return !strings.HasPrefix(ma.Exact, mb.Prefix)
+		}
+	case *proxyconfig.StringMatch_Prefix:
+		switch mb := b.GetMatchType().(type) {
+		case *proxyconfig.StringMatch_Exact:
+			return !strings.HasPrefix(mb.Exact, ma.Prefix)
+		case *proxyconfig.StringMatch_Prefix:
+			return !strings.HasPrefix(ma.Prefix, mb.Prefix) && !strings.HasPrefix(mb.Prefix, ma.Prefix)
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	proxyconfig "istio.io/manager/model/proxy/alphav1/config"
+)
+
+func uriRule(name string, precedence int32, match *proxyconfig.StringMatch) Config {
+	rule := &proxyconfig.RouteRule{Destination: hello, Precedence: precedence}
+	if match != nil {
+		rule.Match = &proxyconfig.MatchCondition{
+			Http: map[string]*proxyconfig.StringMatch{"uri": match},
+		}
+	}
+	return Config{Key: Key{Kind: RouteRule, Name: name, Namespace: "default"}, Content: rule}
+}
+
+func prefix(p string) *proxyconfig.StringMatch {
+	return &proxyconfig.StringMatch{MatchType: &proxyconfig.StringMatch_Prefix{Prefix: p}}
+}
+
+func exact(e string) *proxyconfig.StringMatch {
+	return &proxyconfig.StringMatch{MatchType: &proxyconfig.StringMatch_Exact{Exact: e}}
+}
+
+func TestRouteRulePrecedence(t *testing.T) {
+	configs := []Config{
+		uriRule("b", 1, nil),
+		uriRule("c", 2, nil),
+		uriRule("a", 1, nil),
+	}
+	configs[0].Namespace = "alpha"
+	sort.Sort(RouteRulePrecedence(configs))
+	got := []string{configs[0].Name, configs[1].Name, configs[2].Name}
+	if want := []string{"c", "b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got order %v, want %v", got, want)
+	}
+}
+
+func TestAnalyzeRouteRules(t *testing.T) {
+	cases := []struct {
+		name  string
+		rules []Config
+		want  []ConflictType
+	}{
+		{
+			name:  "specific rule first",
+			rules: []Config{uriRule("api", 2, prefix("/api")), uriRule("all", 1, nil)},
+		},
+		{
+			name:  "disjoint rules",
+			rules: []Config{uriRule("api", 1, prefix("/api")), uriRule("web", 2, prefix("/web"))},
+			want:  nil,
+		},
+		{
+			name:  "tie",
+			rules: []Config{uriRule("api", 1, prefix("/api")), uriRule("web", 1, exact("/web/index.html"))},
+			want:  []ConflictType{ConflictTie},
+		},
+		{
+			name:  "shadowed",
+			rules: []Config{uriRule("api", 2, prefix("/api")), uriRule("all", 3, nil)},
+			want:  []ConflictType{ConflictShadowed},
+		},
+		{
+			name:  "shadowed prefix",
+			rules: []Config{uriRule("v1", 1, exact("/api/v1")), uriRule("api", 2, prefix("/api"))},
+			want:  []ConflictType{ConflictShadowed},
+		},
+		{
+			name: "overlap",
+			rules: []Config{
+				uriRule("api", 2, prefix("/api")),
+				{
+					Key: Key{Kind: RouteRule, Name: "header", Namespace: "default"},
+					Content: &proxyconfig.RouteRule{
+						Destination: hello,
+						Precedence:  1,
+						Match: &proxyconfig.MatchCondition{Http: map[string]*proxyconfig.StringMatch{
+							"Cookie": exact("user=jason"),
+						}},
+					},
+				},
+			},
+			want: []ConflictType{ConflictOverlap},
+		},
+		{
+			name: "different destinations",
+			rules: []Config{
+				uriRule("api", 1, prefix("/api")),
+				{
+					Key:     Key{Kind: RouteRule, Name: "world", Namespace: "default"},
+					Content: &proxyconfig.RouteRule{Destination: world, Precedence: 1},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		conflicts := AnalyzeRouteRules(c.rules)
+		got := make([]ConflictType, 0)
+		for _, conflict := range conflicts {
+			got = append(got, conflict.Type)
+		}
+		if len(got) != len(c.want) || (len(got) > 0 && !reflect.DeepEqual(got, c.want)) {
+			t.Errorf("%s: got conflicts %v, want %v", c.name, conflicts, c.want)
+		}
+	}
+
+	// the conflict names the later rule first
+	conflicts := AnalyzeRouteRules([]Config{uriRule("api", 2, prefix("/api")), uriRule("all", 3, nil)})
+	if len(conflicts) != 1 || conflicts[0].Rule.Name != "api" || conflicts[0].Other.Name != "all" {
+		t.Errorf("unexpected conflicts %v", conflicts)
+	}
+}
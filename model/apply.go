@@ -0,0 +1,113 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// write records a change made by a batch apply for the rollback
+type write struct {
+	key Key
+	// previous content of the object, nil if the object has been created
+	previous proto.Message
+	// revision of the object after the write
+	revision string
+}
+
+// Apply writes a batch of configuration objects to the registry in order.
+//
+// All objects are validated with the kind map before anything is written.
+// Missing objects are created and existing objects are updated. An update
+// uses the revision of the batch object, or the latest revision if the batch
+// object has no revision.
+//
+// If a write fails, the objects written so far are restored to their previous
+// versions in reverse order: created objects are deleted, and updated objects
+// are reverted at the revisions produced by the batch, so that concurrent
+// updates are not overwritten.
+// The returned error lists the failed write and any failure to restore.
+func Apply(registry ConfigRegistry, mapping KindMap, configs []Config) error {
+	var errs error
+	keys := make(map[Key]bool, len(configs))
+	for i := range configs {
+		config := &configs[i]
+		if keys[config.Key] {
+			errs = multierror.Append(errs, fmt.Errorf("Duplicate %v in the batch", config.Key))
+		}
+		keys[config.Key] = true
+		if err := mapping.ValidateConfig(&config.Key, config.Content); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, config.Key.String()+":"))
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+
+	writes := make([]write, 0, len(configs))
+	for _, config := range configs {
+		previous, exists, revision := registry.Get(config.Key)
+		if config.Revision != "" {
+			revision = config.Revision
+		}
+
+		var next string
+		var err error
+		switch {
+		case exists:
+			next, err = registry.Put(config.Key, config.Content, revision)
+		case config.Revision != "":
+			err = &NotFoundError{Key: config.Key}
+		default:
+			previous = nil
+			next, err = registry.Post(config.Key, config.Content)
+		}
+
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("Cannot apply %v: %v", config.Key, err))
+			if err = rollback(registry, writes); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+			return errs
+		}
+		glog.V(2).Infof("Applied %v at revision %s", config.Key, next)
+		writes = append(writes, write{key: config.Key, previous: previous, revision: next})
+	}
+	return nil
+}
+
+// rollback restores the previous versions of the written objects in reverse order
+func rollback(registry ConfigRegistry, writes []write) error {
+	var errs error
+	for i := len(writes) - 1; i >= 0; i-- {
+		w := writes[i]
+		var err error
+		if w.previous == nil {
+			err = registry.Delete(w.key)
+		} else {
+			_, err = registry.Put(w.key, w.previous, w.revision)
+		}
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("Cannot restore %v: %v", w.key, err))
+		} else {
+			glog.V(2).Infof("Restored %v", w.key)
+		}
+	}
+	return errs
+}
@@ -22,6 +22,7 @@ It has these top-level messages:
 	CircuitBreaker
 	HTTPFaultInjection
 	L4FaultInjection
+	ExternalService
 */
 package config
 
@@ -70,6 +71,35 @@ func (LoadBalancing_SimpleLBPolicy) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor0, []int{7, 0}
 }
 
+// DNS resolution of the hostname by the proxy
+type ExternalService_Resolution int32
+
+const (
+	// Resolve the hostname periodically and balance the load over all the
+	// returned addresses
+	ExternalService_STRICT_DNS ExternalService_Resolution = 0
+	// Resolve the hostname for each new connection and use the first
+	// returned address, which suits large web services that return
+	// many rotating addresses
+	ExternalService_LOGICAL_DNS ExternalService_Resolution = 1
+)
+
+var ExternalService_Resolution_name = map[int32]string{
+	0: "STRICT_DNS",
+	1: "LOGICAL_DNS",
+}
+var ExternalService_Resolution_value = map[string]int32{
+	"STRICT_DNS":  0,
+	"LOGICAL_DNS": 1,
+}
+
+func (x ExternalService_Resolution) String() string {
+	return proto.EnumName(ExternalService_Resolution_name, int32(x))
+}
+func (ExternalService_Resolution) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{13, 0}
+}
+
 // Proxy level global configurations go here
 type ProxyMeshConfig struct {
 }
@@ -113,6 +143,11 @@ type Destination struct {
 	L4Fault *L4FaultInjection `protobuf:"bytes,8,opt,name=l4_fault,json=l4Fault" json:"l4_fault,omitempty"`
 	// Custom policy implementations
 	Custom *google_protobuf.Any `protobuf:"bytes,9,opt,name=custom" json:"custom,omitempty"`
+	// Zone aware routing: prefer the instances in the zone of the proxy.
+	// Zone aware routing is a proxy-wide setting: once a policy for any
+	// destination of a proxy requests it, the proxy applies it to all its
+	// destinations. The policy must not select destination tags.
+	ZoneAwareRouting bool `protobuf:"varint,10,opt,name=zone_aware_routing,json=zoneAwareRouting" json:"zone_aware_routing,omitempty"`
 }
 
 func (m *Destination) Reset()                    { *m = Destination{} }
@@ -183,6 +218,13 @@ func (m *Destination) GetCustom() *google_protobuf.Any {
 	return nil
 }
 
+func (m *Destination) GetZoneAwareRouting() bool {
+	if m != nil {
+		return m.ZoneAwareRouting
+	}
+	return false
+}
+
 // Route rule provides a custom routing policy based on the source and
 // destination service versions and connection/request metadata.  The rule must
 // provide a set of conditions for each protocol (TCP, UDP, HTTP) that the
@@ -392,7 +434,9 @@ func (m *L4MatchAttributes) GetDestinationSubnet() []string {
 }
 
 // Describes how to matches a given string (exact match, prefix-based match
-// or posix style regex based match). Match is case-sensitive.
+// or regex based match). Match is case-sensitive. The proxy matches regexes
+// with ECMAScript semantics, so a regex must use the syntax common to RE2 and
+// ECMAScript: no inline flags, named groups, POSIX classes, or \A, \z escapes.
 // It is a validation error to supply a regex for a proxy that does not support it.
 type StringMatch struct {
 	// Types that are valid to be assigned to MatchType:
@@ -1795,6 +1839,94 @@ func (m *L4FaultInjection_Terminate) GetTerminateAfterSeconds() float64 {
 	return 0
 }
 
+// ExternalService declares a service outside of the mesh, such as a
+// third-party API. Calls to the service are proxied like calls to the
+// services in the mesh, so that route rules and destination policies for
+// the hostname apply to the external traffic.
+type ExternalService struct {
+	// REQUIRED. Fully qualified domain name of the service, e.g.
+	// "api.example.com". The hostname is resolved by DNS, and route rules and
+	// destination policies refer to the service by the hostname.
+	Hostname string `protobuf:"bytes,1,opt,name=hostname" json:"hostname,omitempty"`
+	// REQUIRED. Ports of the service
+	Ports []*ExternalService_Port `protobuf:"bytes,2,rep,name=ports" json:"ports,omitempty"`
+	// DNS resolution of the hostname, STRICT_DNS by default
+	Resolution ExternalService_Resolution `protobuf:"varint,3,opt,name=resolution,enum=istio.proxy.v1alpha.config.ExternalService_Resolution" json:"resolution,omitempty"`
+	// Originate TLS connections to the service: the application sends plain
+	// text requests, which the proxy encrypts before sending them to the
+	// external service.
+	TlsOrigination bool `protobuf:"varint,4,opt,name=tls_origination,json=tlsOrigination" json:"tls_origination,omitempty"`
+}
+
+func (m *ExternalService) Reset()                    { *m = ExternalService{} }
+func (m *ExternalService) String() string            { return proto.CompactTextString(m) }
+func (*ExternalService) ProtoMessage()               {}
+func (*ExternalService) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *ExternalService) GetHostname() string {
+	if m != nil {
+		return m.Hostname
+	}
+	return ""
+}
+
+func (m *ExternalService) GetPorts() []*ExternalService_Port {
+	if m != nil {
+		return m.Ports
+	}
+	return nil
+}
+
+func (m *ExternalService) GetResolution() ExternalService_Resolution {
+	if m != nil {
+		return m.Resolution
+	}
+	return ExternalService_STRICT_DNS
+}
+
+func (m *ExternalService) GetTlsOrigination() bool {
+	if m != nil {
+		return m.TlsOrigination
+	}
+	return false
+}
+
+// Port of an external service
+type ExternalService_Port struct {
+	// Name of the port. Required if the service has several ports.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// REQUIRED. Port number
+	Port int32 `protobuf:"varint,2,opt,name=port" json:"port,omitempty"`
+	// REQUIRED. Application protocol of the port: HTTP, HTTP2, GRPC, HTTPS or TCP.
+	Protocol string `protobuf:"bytes,3,opt,name=protocol" json:"protocol,omitempty"`
+}
+
+func (m *ExternalService_Port) Reset()                    { *m = ExternalService_Port{} }
+func (m *ExternalService_Port) String() string            { return proto.CompactTextString(m) }
+func (*ExternalService_Port) ProtoMessage()               {}
+func (*ExternalService_Port) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13, 0} }
+
+func (m *ExternalService_Port) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ExternalService_Port) GetPort() int32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+func (m *ExternalService_Port) GetProtocol() string {
+	if m != nil {
+		return m.Protocol
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*ProxyMeshConfig)(nil), "istio.proxy.v1alpha.config.ProxyMeshConfig")
 	proto.RegisterType((*Destination)(nil), "istio.proxy.v1alpha.config.Destination")
@@ -1818,120 +1950,133 @@ func init() {
 	proto.RegisterType((*L4FaultInjection)(nil), "istio.proxy.v1alpha.config.L4FaultInjection")
 	proto.RegisterType((*L4FaultInjection_Throttle)(nil), "istio.proxy.v1alpha.config.L4FaultInjection.Throttle")
 	proto.RegisterType((*L4FaultInjection_Terminate)(nil), "istio.proxy.v1alpha.config.L4FaultInjection.Terminate")
+	proto.RegisterType((*ExternalService)(nil), "istio.proxy.v1alpha.config.ExternalService")
+	proto.RegisterType((*ExternalService_Port)(nil), "istio.proxy.v1alpha.config.ExternalService.Port")
 	proto.RegisterEnum("istio.proxy.v1alpha.config.LoadBalancing_SimpleLBPolicy", LoadBalancing_SimpleLBPolicy_name, LoadBalancing_SimpleLBPolicy_value)
+	proto.RegisterEnum("istio.proxy.v1alpha.config.ExternalService_Resolution", ExternalService_Resolution_name, ExternalService_Resolution_value)
 }
 
 func init() { proto.RegisterFile("cfg.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1731 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xb4, 0x58, 0xcd, 0x72, 0x1b, 0xc7,
-	0x11, 0x26, 0x00, 0x2e, 0x48, 0x34, 0x28, 0x10, 0x18, 0x51, 0x34, 0x8c, 0x72, 0xb9, 0x68, 0xa6,
-	0x52, 0x52, 0x1c, 0x09, 0xb6, 0x19, 0x45, 0xf2, 0x4f, 0xa9, 0x1c, 0x82, 0xa4, 0x02, 0x39, 0x22,
-	0xc5, 0x0c, 0x61, 0xbb, 0xca, 0x71, 0xb2, 0x19, 0xec, 0x36, 0x80, 0x8d, 0x17, 0xbb, 0x9b, 0xd9,
-	0x59, 0x12, 0x78, 0x85, 0x54, 0xae, 0x79, 0x86, 0xdc, 0xf2, 0x20, 0xc9, 0x2d, 0xc9, 0x2d, 0x0f,
-	0x91, 0xaa, 0x1c, 0x72, 0x4e, 0xcd, 0xcf, 0x2e, 0x16, 0xa0, 0x08, 0x11, 0xae, 0xca, 0x0d, 0xfd,
-	0xf3, 0x7d, 0xdb, 0xdd, 0xd3, 0xd3, 0xd3, 0x24, 0x54, 0x9c, 0xc1, 0xb0, 0x1d, 0xf1, 0x50, 0x84,
-	0xa4, 0xe5, 0xc5, 0xc2, 0x0b, 0xa5, 0x30, 0x99, 0xb6, 0x2f, 0x3f, 0x62, 0x7e, 0x34, 0x62, 0x6d,
-	0x27, 0x0c, 0x06, 0xde, 0xb0, 0xf5, 0xf6, 0x30, 0x0c, 0x87, 0x3e, 0x7e, 0xa0, 0x3c, 0xfb, 0xc9,
-	0xe0, 0x03, 0x16, 0x4c, 0x35, 0xac, 0xf5, 0xee, 0xa2, 0xe9, 0x8a, 0xb3, 0x28, 0x42, 0x1e, 0x6b,
-	0xfb, 0x7e, 0x03, 0xb6, 0xcf, 0x25, 0xe5, 0x29, 0xc6, 0xa3, 0x23, 0xc5, 0xb6, 0xff, 0x17, 0x0b,
-	0xaa, 0xc7, 0x18, 0x0b, 0x2f, 0x60, 0xc2, 0x0b, 0x03, 0xb2, 0x07, 0x55, 0x77, 0x26, 0x36, 0x0b,
-	0x7b, 0x85, 0x07, 0x15, 0x9a, 0x57, 0x91, 0x13, 0x58, 0x17, 0x6c, 0x18, 0x37, 0x8b, 0x7b, 0xa5,
-	0x07, 0xd5, 0x83, 0x8f, 0xda, 0x37, 0x87, 0xda, 0xce, 0x11, 0xb7, 0x7b, 0x6c, 0x18, 0x9f, 0x04,
-	0x82, 0x4f, 0xa9, 0x82, 0x93, 0x73, 0xa8, 0xf9, 0x21, 0x73, 0xed, 0x3e, 0xf3, 0x59, 0xe0, 0x78,
-	0xc1, 0xb0, 0x59, 0xda, 0x2b, 0x3c, 0xa8, 0x1e, 0xfc, 0x68, 0x19, 0xe1, 0xcb, 0x90, 0xb9, 0x9d,
-	0x14, 0x40, 0xef, 0xf8, 0x79, 0x91, 0x5c, 0xc0, 0xb6, 0xe3, 0x71, 0x27, 0xf1, 0x84, 0xdd, 0xe7,
-	0xc8, 0xbe, 0x43, 0xde, 0x5c, 0x57, 0x94, 0xef, 0x2f, 0xa3, 0x3c, 0xd2, 0x90, 0x8e, 0x46, 0xd0,
-	0x9a, 0x33, 0x27, 0x93, 0x2f, 0x60, 0x6b, 0x24, 0x44, 0x64, 0x0b, 0x6f, 0x8c, 0x61, 0x22, 0x9a,
-	0x96, 0x62, 0xbc, 0xbf, 0x8c, 0xb1, 0xdb, 0xeb, 0x9d, 0xf7, 0xb4, 0x3b, 0xad, 0x4a, 0xb0, 0x11,
-	0xc8, 0x31, 0x80, 0xe2, 0xe2, 0x28, 0xf8, 0xb4, 0x59, 0x56, 0x4c, 0x3f, 0x7c, 0x13, 0x13, 0x95,
-	0xce, 0xb4, 0x22, 0x81, 0xea, 0x27, 0x39, 0x35, 0x2c, 0x03, 0x96, 0xf8, 0xa2, 0xb9, 0xa1, 0x58,
-	0xda, 0x6f, 0x62, 0x79, 0x2e, 0x9d, 0x5f, 0x04, 0xbf, 0x43, 0x47, 0x1e, 0x86, 0xa6, 0x53, 0x3a,
-	0xf2, 0x73, 0xd8, 0xf4, 0x1f, 0x1b, 0xb2, 0x4d, 0x45, 0xf6, 0x70, 0xe9, 0x09, 0x3c, 0x5e, 0xa0,
-	0xda, 0xf0, 0xb5, 0x86, 0x3c, 0x84, 0xb2, 0x93, 0xc4, 0x22, 0x1c, 0x37, 0x2b, 0x8a, 0x66, 0xa7,
-	0xad, 0xbb, 0xb1, 0x9d, 0x76, 0x63, 0xfb, 0x30, 0x98, 0x52, 0xe3, 0xd3, 0x7a, 0x0a, 0x95, 0xac,
-	0x23, 0x48, 0x1d, 0x4a, 0xdf, 0xe1, 0xd4, 0x34, 0x9b, 0xfc, 0x49, 0x76, 0xc0, 0xba, 0x64, 0x7e,
-	0x82, 0xcd, 0xa2, 0xd2, 0x69, 0xe1, 0xd3, 0xe2, 0xc7, 0x85, 0xfd, 0x7f, 0x16, 0xa0, 0x42, 0xc3,
-	0x44, 0x20, 0x4d, 0x7c, 0xbc, 0x45, 0xbb, 0xfe, 0x0c, 0xac, 0x31, 0x13, 0xce, 0x48, 0x31, 0xbd,
-	0xa1, 0x17, 0x4e, 0xa5, 0xe3, 0x51, 0x18, 0xb8, 0x9e, 0x4a, 0x4d, 0x03, 0xc9, 0x11, 0x58, 0x5c,
-	0x7e, 0xb0, 0x59, 0x52, 0x1d, 0xff, 0xe8, 0x96, 0x1d, 0xff, 0x35, 0x7a, 0xc3, 0x91, 0xa0, 0x1a,
-	0x4b, 0xde, 0x05, 0x88, 0x38, 0x3a, 0xe8, 0x62, 0xe0, 0xa0, 0xea, 0x4b, 0x8b, 0xe6, 0x34, 0xfb,
-	0xff, 0x29, 0x41, 0x6d, 0xfe, 0xf3, 0x64, 0x17, 0xca, 0x71, 0x98, 0x70, 0x07, 0x4d, 0x5a, 0x46,
-	0x22, 0xbf, 0x82, 0xaa, 0xfe, 0x65, 0xe7, 0xee, 0xe1, 0xa7, 0xb7, 0xcf, 0xab, 0x7d, 0xa1, 0xd0,
-	0xb3, 0x0b, 0x09, 0x71, 0xa6, 0x20, 0x9f, 0x43, 0x49, 0x38, 0x91, 0xb9, 0x8b, 0x8f, 0x96, 0x77,
-	0x82, 0xa2, 0x3d, 0x14, 0x82, 0x7b, 0xfd, 0x44, 0x60, 0x4c, 0x25, 0x52, 0x12, 0x24, 0x6e, 0x64,
-	0x6e, 0xde, 0xaa, 0x04, 0x89, 0x1b, 0x91, 0x2e, 0xac, 0xcb, 0xee, 0x6c, 0x5a, 0x2a, 0xaf, 0xc7,
-	0x2b, 0xe4, 0xd5, 0x15, 0x22, 0x32, 0x23, 0x46, 0x32, 0xb4, 0x9e, 0xc1, 0xf6, 0x42, 0xaa, 0xab,
-	0x74, 0x5a, 0xeb, 0xb7, 0x50, 0xc9, 0x18, 0x5f, 0x03, 0x7c, 0x96, 0x07, 0xbe, 0x61, 0x24, 0x5c,
-	0x08, 0xee, 0x05, 0x43, 0x15, 0x6e, 0xbe, 0x97, 0xff, 0x51, 0x80, 0xc6, 0xb5, 0x8e, 0xb9, 0x45,
-	0x4f, 0xff, 0x62, 0x6e, 0x04, 0x3f, 0x5d, 0xa9, 0x21, 0xaf, 0x0d, 0xe2, 0x5d, 0x28, 0x5f, 0x29,
-	0x8b, 0x3a, 0x74, 0x8b, 0x1a, 0xe9, 0xfb, 0xdf, 0xd0, 0x21, 0x34, 0xae, 0x1d, 0x2d, 0xf9, 0x01,
-	0xdc, 0x31, 0x4d, 0x1b, 0x27, 0xfd, 0x00, 0x45, 0xb3, 0xb0, 0x57, 0x7a, 0x50, 0xa1, 0x5b, 0x5a,
-	0x79, 0xa1, 0x74, 0xe4, 0x11, 0x90, 0x5c, 0x9a, 0xa9, 0x67, 0x51, 0x79, 0x36, 0x72, 0x16, 0xed,
-	0xbe, 0x8f, 0x50, 0xcd, 0x15, 0x96, 0xec, 0x82, 0x85, 0x13, 0xe6, 0x08, 0x1d, 0x65, 0x77, 0x8d,
-	0x6a, 0x91, 0x34, 0xa1, 0x1c, 0x71, 0x1c, 0x78, 0x13, 0x1d, 0x6a, 0x77, 0x8d, 0x1a, 0x59, 0x22,
-	0x38, 0x0e, 0x71, 0xa2, 0x32, 0x57, 0x08, 0x25, 0x76, 0xb6, 0x00, 0xd4, 0xd5, 0xb7, 0xc5, 0x34,
-	0xc2, 0xfd, 0x7f, 0x15, 0xe0, 0xce, 0xdc, 0xc3, 0x43, 0xce, 0x60, 0x3d, 0x60, 0x63, 0x7d, 0x2f,
-	0x6b, 0x07, 0x1f, 0xdf, 0xfa, 0xc5, 0x6a, 0x5f, 0x78, 0xe3, 0xc8, 0xc7, 0x97, 0x9d, 0xf3, 0xd0,
-	0xf7, 0x9c, 0x69, 0x77, 0x8d, 0x2a, 0x1e, 0xd2, 0xce, 0x46, 0x67, 0xf1, 0xe6, 0xd1, 0x29, 0xe3,
-	0xd6, 0x5e, 0xfb, 0xcf, 0xa0, 0x36, 0xcf, 0x44, 0xb6, 0xa1, 0x4a, 0x5f, 0x7d, 0x79, 0x76, 0x6c,
-	0xd3, 0x57, 0x9d, 0x17, 0x67, 0xf5, 0x35, 0x52, 0x03, 0x78, 0x79, 0x72, 0x78, 0xd1, 0xb3, 0x8f,
-	0x5e, 0x9d, 0x9d, 0xd5, 0x0b, 0x04, 0xa0, 0x4c, 0x0f, 0xcf, 0x8e, 0x5f, 0x9d, 0xd6, 0x4b, 0x9d,
-	0x2a, 0x54, 0xfc, 0xbe, 0x1d, 0x29, 0xe4, 0xfe, 0x9f, 0x8b, 0x50, 0xcd, 0xbd, 0x58, 0xc4, 0x85,
-	0x5a, 0xac, 0xb8, 0xb3, 0x27, 0xaf, 0xa0, 0x62, 0xfa, 0xec, 0x96, 0x4f, 0x9e, 0xc9, 0xd1, 0x48,
-	0x59, 0xa2, 0x77, 0xe2, 0xbc, 0x7a, 0xd5, 0x8c, 0x5b, 0x11, 0xdc, 0x7d, 0x0d, 0x2f, 0xb9, 0x0f,
-	0xdb, 0x26, 0x4a, 0x3b, 0x46, 0x27, 0x0c, 0xdc, 0x58, 0x45, 0x5b, 0xa0, 0x35, 0xa3, 0xbe, 0xd0,
-	0x5a, 0xf2, 0x21, 0xec, 0x84, 0x97, 0xc8, 0xb9, 0xe7, 0xa2, 0x3d, 0x42, 0xe6, 0x22, 0xb7, 0xd5,
-	0x09, 0xea, 0xe6, 0x25, 0xa9, 0xad, 0xab, 0x4c, 0x67, 0x6c, 0x8c, 0x9d, 0x3a, 0xa4, 0x1c, 0x69,
-	0xa5, 0xfe, 0x58, 0x84, 0x4a, 0xf6, 0x22, 0x93, 0x6f, 0x61, 0xcb, 0xd4, 0x49, 0x3f, 0xe7, 0xba,
-	0x4a, 0x4f, 0x6f, 0xf5, 0x9c, 0x9b, 0x1a, 0xa9, 0xdf, 0x59, 0x85, 0xaa, 0xf1, 0x4c, 0xb9, 0x72,
-	0x7d, 0x18, 0x34, 0xae, 0x71, 0x92, 0x16, 0x6c, 0x32, 0x21, 0x70, 0x1c, 0x09, 0x5d, 0x16, 0x8b,
-	0x66, 0xf2, 0xf7, 0x28, 0x48, 0x0d, 0xb6, 0x54, 0xa6, 0x69, 0x39, 0xfe, 0x66, 0x41, 0x6d, 0x7e,
-	0x79, 0x22, 0x2e, 0x54, 0x4c, 0x4d, 0x9c, 0xbe, 0x29, 0xc8, 0xc9, 0xed, 0x77, 0x2f, 0x53, 0x95,
-	0x79, 0x65, 0x56, 0x9e, 0x4d, 0xcd, 0x7c, 0xd4, 0x5f, 0xb9, 0x36, 0x7f, 0x5a, 0x87, 0xd6, 0xcd,
-	0xd4, 0xe4, 0xc7, 0xd0, 0x88, 0x13, 0xc7, 0xc1, 0x38, 0xb6, 0xc5, 0x88, 0x63, 0x3c, 0x0a, 0x7d,
-	0xd7, 0x94, 0xab, 0x6e, 0x0c, 0xbd, 0x54, 0x2f, 0x9d, 0x07, 0xcc, 0xf3, 0x13, 0x8e, 0x39, 0xe7,
-	0xa2, 0x76, 0x36, 0x86, 0x99, 0xf3, 0x01, 0xdc, 0xe3, 0x18, 0xa3, 0xb0, 0x17, 0x7b, 0xb4, 0xa4,
-	0x7a, 0xf4, 0xae, 0x32, 0xf6, 0xe6, 0x1b, 0xf5, 0x3e, 0x6c, 0x8f, 0xd9, 0xc4, 0x76, 0xc2, 0x20,
-	0xd0, 0x0b, 0x56, 0x6c, 0x96, 0x85, 0xda, 0x98, 0x4d, 0x8e, 0x66, 0x5a, 0xf2, 0x09, 0xbc, 0xad,
-	0xd6, 0x40, 0xe9, 0x1d, 0x61, 0xe0, 0x7a, 0xc1, 0xd0, 0xe6, 0xf8, 0xfb, 0x04, 0x63, 0x11, 0xab,
-	0x2d, 0xd5, 0xa2, 0xbb, 0xd2, 0xe1, 0x94, 0x4d, 0xce, 0xb5, 0x99, 0x1a, 0x2b, 0x79, 0x1f, 0x1a,
-	0x19, 0x34, 0x83, 0x94, 0x15, 0x64, 0xdb, 0x40, 0x32, 0xdf, 0xf7, 0x60, 0x2b, 0xf6, 0x11, 0x23,
-	0xfb, 0xca, 0x0b, 0xdc, 0xf0, 0x4a, 0xed, 0x9b, 0x15, 0x5a, 0x55, 0xba, 0xaf, 0x95, 0x8a, 0x3c,
-	0x81, 0xb7, 0x14, 0x9d, 0x13, 0x06, 0x31, 0x3a, 0x89, 0xf0, 0x2e, 0xd1, 0x46, 0xce, 0x43, 0x1e,
-	0xab, 0x85, 0xd2, 0xa2, 0xf7, 0xa4, 0xf9, 0x68, 0x66, 0x3d, 0x51, 0xc6, 0x0c, 0xe7, 0xa2, 0xd0,
-	0x49, 0xd9, 0x5e, 0x20, 0x90, 0x5f, 0x32, 0x5f, 0x6d, 0x90, 0x06, 0x77, 0x9c, 0x5a, 0x5f, 0x18,
-	0x23, 0x79, 0x0e, 0x7b, 0xd7, 0xc2, 0xb7, 0x23, 0xe4, 0xb9, 0xa2, 0x35, 0x41, 0x11, 0xbc, 0xb3,
-	0x90, 0xcd, 0x39, 0xf2, 0x59, 0x09, 0xe5, 0x18, 0x74, 0xb2, 0x31, 0xf8, 0xdf, 0x0d, 0x20, 0xd7,
-	0x17, 0x65, 0xf2, 0x05, 0x58, 0x2e, 0xfa, 0x2c, 0xbd, 0xde, 0x8f, 0x57, 0xdb, 0xb3, 0xdb, 0xc7,
-	0x12, 0x4b, 0x35, 0x85, 0xe4, 0x62, 0xfd, 0x90, 0x0b, 0xd3, 0xb6, 0xab, 0x72, 0x1d, 0x4a, 0x2c,
-	0xd5, 0x14, 0xe4, 0x4b, 0xd8, 0xd0, 0xb7, 0x36, 0x36, 0x5b, 0xe9, 0x67, 0x2b, 0xb2, 0xe9, 0x8b,
-	0x6d, 0x16, 0x81, 0x94, 0xab, 0xe5, 0xc0, 0x56, 0xde, 0xf0, 0x7f, 0xd9, 0x7a, 0x5a, 0x7f, 0x28,
-	0x82, 0xa5, 0x0a, 0x43, 0xbe, 0x85, 0xea, 0xc0, 0x9b, 0xa0, 0x6b, 0xe7, 0x6b, 0xfc, 0xc9, 0x8a,
-	0x99, 0x3c, 0x97, 0x0c, 0x8a, 0xaf, 0xbb, 0x46, 0x61, 0x90, 0x49, 0xe4, 0x37, 0x50, 0xc1, 0x49,
-	0x64, 0xb8, 0x75, 0xb8, 0x9f, 0xaf, 0xc8, 0x7d, 0x32, 0x89, 0xc2, 0x00, 0x03, 0xe1, 0x31, 0x3f,
-	0xfd, 0xc2, 0x26, 0x4e, 0x22, 0xcd, 0x7f, 0xd3, 0x08, 0x2d, 0xdd, 0x38, 0x42, 0x1b, 0xb0, 0x6d,
-	0x3a, 0xde, 0x67, 0x53, 0xb5, 0x5c, 0xb4, 0xbe, 0x02, 0x98, 0x25, 0x40, 0x9a, 0xb0, 0x11, 0x21,
-	0x77, 0x30, 0xd0, 0xaf, 0x6e, 0x91, 0xa6, 0x22, 0x69, 0xc3, 0xdd, 0x5c, 0xa9, 0xb2, 0x49, 0x52,
-	0x54, 0x93, 0xa4, 0x31, 0xcb, 0xda, 0xcc, 0x91, 0xd6, 0x37, 0x50, 0x5f, 0x0c, 0x7e, 0x09, 0xfb,
-	0x43, 0x20, 0x63, 0x64, 0xc1, 0x6b, 0xc9, 0xeb, 0xd2, 0x32, 0xc7, 0xfd, 0xd7, 0x02, 0x58, 0xaa,
-	0x1b, 0x97, 0x30, 0xbe, 0x07, 0xd5, 0x21, 0x8f, 0x1c, 0x3b, 0x16, 0x4c, 0x24, 0x71, 0xb6, 0x79,
-	0x81, 0x54, 0x5e, 0x28, 0x9d, 0x74, 0x91, 0xd5, 0x38, 0xd0, 0xc3, 0x22, 0xdb, 0xc1, 0xd4, 0x5f,
-	0xb7, 0x07, 0x6a, 0x46, 0xa4, 0x2e, 0x29, 0x8b, 0x9a, 0x84, 0xa9, 0x8b, 0x61, 0xb9, 0xe9, 0x14,
-	0xac, 0x1b, 0x4f, 0x61, 0x0b, 0x40, 0x7d, 0x51, 0x6f, 0x77, 0xff, 0x5e, 0x87, 0xfa, 0xe2, 0x1f,
-	0xb5, 0xe4, 0x97, 0xb0, 0x29, 0x46, 0x3c, 0x14, 0xc2, 0x47, 0xd3, 0x95, 0x3f, 0x5d, 0xe5, 0x8f,
-	0xe2, 0x76, 0xcf, 0x80, 0x69, 0x46, 0x43, 0x7a, 0x50, 0x11, 0xc8, 0xc7, 0x72, 0x83, 0x4d, 0x2f,
-	0xcf, 0x93, 0xd5, 0x38, 0x53, 0x34, 0x9d, 0x11, 0xb5, 0xfe, 0x5e, 0x84, 0xcd, 0xf4, 0x63, 0x4b,
-	0x4e, 0xe3, 0x43, 0xd8, 0x71, 0xc3, 0xab, 0x20, 0x16, 0x1c, 0xd9, 0xd8, 0xf6, 0xbd, 0xb1, 0x27,
-	0xec, 0x7e, 0xa4, 0x8f, 0xa5, 0x44, 0xc9, 0xcc, 0xf6, 0x52, 0x9a, 0x3a, 0x51, 0x2c, 0x3b, 0x22,
-	0x89, 0xae, 0xf9, 0x97, 0x94, 0x7f, 0x3d, 0xb5, 0x64, 0xde, 0x4f, 0x60, 0x37, 0x4d, 0xd4, 0x66,
-	0x03, 0x81, 0x3c, 0xeb, 0x21, 0x79, 0x64, 0x85, 0xee, 0x1a, 0xdd, 0x49, 0xed, 0x87, 0xd2, 0x9c,
-	0xbe, 0x76, 0x07, 0xb0, 0xb3, 0x80, 0xeb, 0x4f, 0x05, 0xea, 0xf7, 0x4b, 0xa2, 0xc8, 0x1c, 0xaa,
-	0x23, 0x6d, 0xe4, 0x2c, 0x87, 0x19, 0x84, 0xb3, 0x2f, 0xe9, 0xff, 0xa7, 0xbc, 0x73, 0x6d, 0x19,
-	0x38, 0x0e, 0x93, 0xbe, 0x8f, 0x5f, 0xc9, 0xf1, 0x33, 0xe3, 0x7b, 0x1e, 0xa6, 0x31, 0xa8, 0x45,
-	0x6f, 0x2e, 0x86, 0xd6, 0xaf, 0xa1, 0x92, 0x15, 0x7b, 0x49, 0x51, 0x9f, 0xc0, 0x5b, 0xd9, 0x41,
-	0x2c, 0x64, 0xad, 0x6f, 0xce, 0xbd, 0xcc, 0x9c, 0x4f, 0xba, 0xb3, 0xf9, 0x4d, 0x59, 0x9f, 0x71,
-	0xbf, 0xac, 0x82, 0xfc, 0xc9, 0xff, 0x02, 0x00, 0x00, 0xff, 0xff, 0x18, 0x4b, 0x77, 0xea, 0xfa,
-	0x13, 0x00, 0x00,
+	// 1904 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0xdd, 0x72, 0x23, 0x47,
+	0x15, 0xb6, 0x7e, 0xc6, 0x6b, 0x1d, 0x79, 0x65, 0xb9, 0xd7, 0xeb, 0x4c, 0x54, 0xa9, 0x94, 0x23,
+	0x8a, 0x5a, 0x13, 0x76, 0x95, 0x8d, 0x59, 0xbc, 0xf9, 0xa9, 0xad, 0x60, 0xc9, 0x76, 0xec, 0xc5,
+	0x96, 0x4d, 0x4b, 0xd9, 0x54, 0x85, 0xc0, 0xd0, 0x9a, 0x69, 0x49, 0x43, 0x46, 0x33, 0x43, 0x4f,
+	0x8f, 0x2d, 0xf1, 0x08, 0x14, 0xb7, 0x3c, 0x03, 0xef, 0x02, 0x77, 0xc0, 0x1d, 0xbc, 0x03, 0x05,
+	0x17, 0x5c, 0x53, 0xfd, 0x33, 0xa3, 0x91, 0xbc, 0xd6, 0x5a, 0x5b, 0x95, 0x3b, 0x9d, 0x9f, 0xef,
+	0x9b, 0x3e, 0xa7, 0x4f, 0x9f, 0x3e, 0x2d, 0x28, 0xd9, 0xfd, 0x41, 0x23, 0x64, 0x01, 0x0f, 0x50,
+	0xcd, 0x8d, 0xb8, 0x1b, 0x08, 0x61, 0x3c, 0x69, 0x5c, 0x7d, 0x4c, 0xbc, 0x70, 0x48, 0x1a, 0x76,
+	0xe0, 0xf7, 0xdd, 0x41, 0xed, 0xdd, 0x41, 0x10, 0x0c, 0x3c, 0xfa, 0x91, 0xf4, 0xec, 0xc5, 0xfd,
+	0x8f, 0x88, 0x3f, 0x51, 0xb0, 0xda, 0xfb, 0xf3, 0xa6, 0x6b, 0x46, 0xc2, 0x90, 0xb2, 0x48, 0xd9,
+	0xeb, 0x9b, 0xb0, 0x71, 0x29, 0x28, 0xcf, 0x69, 0x34, 0x6c, 0x49, 0xb6, 0xfa, 0xbf, 0x0c, 0x28,
+	0x1f, 0xd2, 0x88, 0xbb, 0x3e, 0xe1, 0x6e, 0xe0, 0xa3, 0x1d, 0x28, 0x3b, 0x53, 0xd1, 0xcc, 0xed,
+	0xe4, 0x76, 0x4b, 0x38, 0xab, 0x42, 0x47, 0x50, 0xe4, 0x64, 0x10, 0x99, 0xf9, 0x9d, 0xc2, 0x6e,
+	0x79, 0xef, 0xe3, 0xc6, 0xed, 0x4b, 0x6d, 0x64, 0x88, 0x1b, 0x5d, 0x32, 0x88, 0x8e, 0x7c, 0xce,
+	0x26, 0x58, 0xc2, 0xd1, 0x25, 0x54, 0xbc, 0x80, 0x38, 0x56, 0x8f, 0x78, 0xc4, 0xb7, 0x5d, 0x7f,
+	0x60, 0x16, 0x76, 0x72, 0xbb, 0xe5, 0xbd, 0x1f, 0x2d, 0x22, 0x3c, 0x0b, 0x88, 0xd3, 0x4c, 0x00,
+	0xf8, 0xbe, 0x97, 0x15, 0x51, 0x07, 0x36, 0x6c, 0x97, 0xd9, 0xb1, 0xcb, 0xad, 0x1e, 0xa3, 0xe4,
+	0x3b, 0xca, 0xcc, 0xa2, 0xa4, 0xfc, 0x70, 0x11, 0x65, 0x4b, 0x41, 0x9a, 0x0a, 0x81, 0x2b, 0xf6,
+	0x8c, 0x8c, 0x5e, 0xc2, 0xfa, 0x90, 0xf3, 0xd0, 0xe2, 0xee, 0x88, 0x06, 0x31, 0x37, 0x0d, 0xc9,
+	0xf8, 0x68, 0x11, 0xe3, 0x49, 0xb7, 0x7b, 0xd9, 0x55, 0xee, 0xb8, 0x2c, 0xc0, 0x5a, 0x40, 0x87,
+	0x00, 0x92, 0x8b, 0x51, 0xce, 0x26, 0xe6, 0xaa, 0x64, 0xfa, 0xe1, 0x9b, 0x98, 0xb0, 0x70, 0xc6,
+	0x25, 0x01, 0x94, 0x3f, 0xd1, 0xb9, 0x66, 0xe9, 0x93, 0xd8, 0xe3, 0xe6, 0x3d, 0xc9, 0xd2, 0x78,
+	0x13, 0xcb, 0xb1, 0x70, 0x3e, 0xf5, 0x7f, 0x4b, 0x6d, 0xb1, 0x19, 0x8a, 0x4e, 0xea, 0xd0, 0x97,
+	0xb0, 0xe6, 0x3d, 0xd3, 0x64, 0x6b, 0x92, 0xec, 0xf1, 0xc2, 0x1d, 0x78, 0x36, 0x47, 0x75, 0xcf,
+	0x53, 0x1a, 0xf4, 0x18, 0x56, 0xed, 0x38, 0xe2, 0xc1, 0xc8, 0x2c, 0x49, 0x9a, 0xad, 0x86, 0xaa,
+	0xc6, 0x46, 0x52, 0x8d, 0x8d, 0x03, 0x7f, 0x82, 0xb5, 0x0f, 0x7a, 0x0c, 0xe8, 0xf7, 0x81, 0x4f,
+	0x2d, 0x72, 0x4d, 0x18, 0xb5, 0x58, 0x10, 0x73, 0x51, 0x02, 0xb0, 0x93, 0xdb, 0x5d, 0xc3, 0x55,
+	0x61, 0x39, 0x10, 0x06, 0xac, 0xf4, 0xb5, 0xe7, 0x50, 0x4a, 0xeb, 0x07, 0x55, 0xa1, 0xf0, 0x1d,
+	0x9d, 0xe8, 0xd2, 0x14, 0x3f, 0xd1, 0x16, 0x18, 0x57, 0xc4, 0x8b, 0xa9, 0x99, 0x97, 0x3a, 0x25,
+	0x7c, 0x96, 0xff, 0x24, 0x57, 0xff, 0x47, 0x0e, 0x4a, 0x82, 0x84, 0xe2, 0xd8, 0xa3, 0x77, 0x28,
+	0xee, 0x9f, 0x81, 0x31, 0x22, 0xdc, 0x1e, 0x9a, 0xf9, 0x37, 0x57, 0xce, 0xb9, 0x70, 0x6c, 0x05,
+	0xbe, 0xe3, 0xca, 0x44, 0x28, 0x20, 0x6a, 0x81, 0x21, 0xa2, 0xa1, 0x66, 0x41, 0x9e, 0x8f, 0x27,
+	0x77, 0x3c, 0x1f, 0x5f, 0x53, 0x77, 0x30, 0xe4, 0x58, 0x61, 0xd1, 0xfb, 0x00, 0x21, 0xa3, 0x36,
+	0x75, 0xa8, 0x6f, 0x53, 0x59, 0xc5, 0x06, 0xce, 0x68, 0xea, 0xff, 0x2d, 0x40, 0x65, 0xf6, 0xf3,
+	0x68, 0x1b, 0x56, 0xa3, 0x20, 0x66, 0x36, 0xd5, 0x61, 0x69, 0x09, 0xfd, 0x12, 0xca, 0xea, 0x97,
+	0x95, 0x39, 0xb5, 0x9f, 0xdd, 0x3d, 0xae, 0x46, 0x47, 0xa2, 0xa7, 0xc7, 0x17, 0xa2, 0x54, 0x81,
+	0xbe, 0x80, 0x02, 0xb7, 0x43, 0x7d, 0x72, 0x9f, 0x2c, 0xae, 0x1b, 0x49, 0x7b, 0xc0, 0x39, 0x73,
+	0x7b, 0x31, 0xa7, 0x11, 0x16, 0x48, 0x41, 0x10, 0x3b, 0xa1, 0x59, 0x7c, 0x2b, 0x82, 0xd8, 0x09,
+	0xd1, 0x09, 0x14, 0x45, 0x2d, 0x9b, 0x86, 0x8c, 0xeb, 0xd9, 0x12, 0x71, 0x9d, 0x70, 0x1e, 0xea,
+	0x86, 0x24, 0x18, 0x6a, 0x2f, 0x60, 0x63, 0x2e, 0xd4, 0x65, 0x2a, 0xad, 0xf6, 0x1b, 0x28, 0xa5,
+	0x8c, 0xaf, 0x01, 0xbe, 0xc8, 0x02, 0xdf, 0xd0, 0x40, 0x3a, 0x9c, 0xb9, 0xfe, 0x40, 0x2e, 0x37,
+	0x5b, 0xcb, 0x7f, 0xcf, 0xc1, 0xe6, 0x8d, 0x8a, 0xb9, 0x43, 0x4d, 0xff, 0x7c, 0xa6, 0x61, 0x3f,
+	0x5f, 0xaa, 0x20, 0x6f, 0xb4, 0xed, 0x6d, 0x58, 0xbd, 0x96, 0x16, 0xb9, 0xe9, 0x06, 0xd6, 0xd2,
+	0xdb, 0x9f, 0xd0, 0x01, 0x6c, 0xde, 0xd8, 0x5a, 0xf4, 0x03, 0xb8, 0xaf, 0x8b, 0x36, 0x8a, 0x7b,
+	0x3e, 0xe5, 0x66, 0x6e, 0xa7, 0xb0, 0x5b, 0xc2, 0xeb, 0x4a, 0xd9, 0x91, 0x3a, 0xf4, 0x04, 0x50,
+	0x26, 0xcc, 0xc4, 0x33, 0x2f, 0x3d, 0x37, 0x33, 0x16, 0xe5, 0x5e, 0xa7, 0x50, 0xce, 0x24, 0x16,
+	0x6d, 0x83, 0x41, 0xc7, 0xc4, 0xe6, 0x6a, 0x95, 0x27, 0x2b, 0x58, 0x89, 0xc8, 0x84, 0xd5, 0x90,
+	0xd1, 0xbe, 0x3b, 0x56, 0x4b, 0x3d, 0x59, 0xc1, 0x5a, 0x16, 0x08, 0x46, 0x07, 0x74, 0x6c, 0x16,
+	0xb4, 0x41, 0x89, 0xcd, 0x75, 0x00, 0x79, 0xf4, 0x2d, 0x3e, 0x09, 0x69, 0xfd, 0x9f, 0x39, 0xb8,
+	0x3f, 0x73, 0x4d, 0xa1, 0x36, 0x14, 0x7d, 0x32, 0x52, 0xe7, 0xb2, 0xb2, 0xf7, 0xc9, 0x9d, 0xef,
+	0xb7, 0x46, 0xc7, 0x1d, 0x85, 0x1e, 0x3d, 0x6b, 0x5e, 0x06, 0x9e, 0x6b, 0x4f, 0x4e, 0x56, 0xb0,
+	0xe4, 0x41, 0x8d, 0xb4, 0xd1, 0xe6, 0x6f, 0x6f, 0xb4, 0x62, 0xdd, 0xca, 0xab, 0xfe, 0x02, 0x2a,
+	0xb3, 0x4c, 0x68, 0x03, 0xca, 0xf8, 0xe2, 0xab, 0xf6, 0xa1, 0x85, 0x2f, 0x9a, 0xa7, 0xed, 0xea,
+	0x0a, 0xaa, 0x00, 0x9c, 0x1d, 0x1d, 0x74, 0xba, 0x56, 0xeb, 0xa2, 0xdd, 0xae, 0xe6, 0x10, 0xc0,
+	0x2a, 0x3e, 0x68, 0x1f, 0x5e, 0x9c, 0x57, 0x0b, 0xcd, 0x32, 0x94, 0xbc, 0x9e, 0x15, 0x4a, 0x64,
+	0xfd, 0xcf, 0x79, 0x28, 0x67, 0xee, 0x37, 0xe4, 0x40, 0x25, 0x92, 0xdc, 0xe9, 0x05, 0x99, 0x93,
+	0x6b, 0xfa, 0xfc, 0x8e, 0x17, 0xa4, 0x8e, 0x51, 0x4b, 0x69, 0xa0, 0xf7, 0xa3, 0xac, 0x7a, 0xd9,
+	0x88, 0x6b, 0x21, 0x3c, 0x78, 0x0d, 0x2f, 0x7a, 0x04, 0x1b, 0x7a, 0x95, 0x56, 0x44, 0xed, 0xc0,
+	0x77, 0x22, 0xb9, 0xda, 0x1c, 0xae, 0x68, 0x75, 0x47, 0x69, 0xd1, 0x53, 0xd8, 0x0a, 0xae, 0x28,
+	0x63, 0xae, 0x43, 0xad, 0x21, 0x25, 0x0e, 0x65, 0x96, 0xdc, 0x41, 0x55, 0xbc, 0x28, 0xb1, 0x9d,
+	0x48, 0x53, 0x9b, 0x8c, 0x68, 0xb3, 0x0a, 0x09, 0x47, 0x92, 0xa9, 0x3f, 0xe6, 0xa1, 0x94, 0xde,
+	0xdf, 0xe8, 0x5b, 0x58, 0xd7, 0x79, 0x52, 0x97, 0xbf, 0xca, 0xd2, 0xf3, 0x3b, 0x5d, 0xfe, 0x3a,
+	0x47, 0xf2, 0x77, 0x9a, 0xa1, 0x72, 0x34, 0x55, 0x2e, 0x9d, 0x1f, 0x02, 0x9b, 0x37, 0x38, 0x51,
+	0x0d, 0xd6, 0x08, 0xe7, 0x74, 0x14, 0x72, 0x95, 0x16, 0x03, 0xa7, 0xf2, 0x5b, 0x24, 0xa4, 0x02,
+	0xeb, 0x32, 0xd2, 0x24, 0x1d, 0x7f, 0x35, 0xa0, 0x32, 0x3b, 0x6a, 0x21, 0x07, 0x4a, 0x3a, 0x27,
+	0x76, 0x4f, 0x27, 0xe4, 0xe8, 0xee, 0x93, 0x9a, 0xce, 0xca, 0xac, 0x32, 0x4d, 0xcf, 0x9a, 0x62,
+	0x6e, 0xf5, 0x96, 0xce, 0xcd, 0x9f, 0x8a, 0x50, 0xbb, 0x9d, 0x1a, 0xfd, 0x18, 0x36, 0xa3, 0xd8,
+	0xb6, 0x69, 0x14, 0x59, 0x7c, 0xc8, 0x68, 0x34, 0x0c, 0x3c, 0x47, 0xa7, 0xab, 0xaa, 0x0d, 0xdd,
+	0x44, 0x2f, 0x9c, 0xfb, 0xc4, 0xf5, 0x62, 0x46, 0x33, 0xce, 0x79, 0xe5, 0xac, 0x0d, 0x53, 0xe7,
+	0x3d, 0x78, 0xc8, 0x68, 0x44, 0xb9, 0x35, 0x5f, 0xa3, 0x05, 0x59, 0xa3, 0x0f, 0xa4, 0xb1, 0x3b,
+	0x5b, 0xa8, 0x8f, 0x60, 0x63, 0x44, 0xc6, 0x96, 0x1d, 0xf8, 0xbe, 0x1a, 0xc7, 0x22, 0x3d, 0x2c,
+	0x54, 0x46, 0x64, 0xdc, 0x9a, 0x6a, 0xd1, 0xa7, 0xf0, 0xae, 0x1c, 0x1a, 0x85, 0x77, 0x48, 0x7d,
+	0xc7, 0xf5, 0x07, 0x16, 0xa3, 0xbf, 0x8b, 0x69, 0xc4, 0x23, 0x39, 0xd3, 0x1a, 0x78, 0x5b, 0x38,
+	0x9c, 0x93, 0xf1, 0xa5, 0x32, 0x63, 0x6d, 0x45, 0x1f, 0xc2, 0x66, 0x0a, 0x4d, 0x21, 0xab, 0x12,
+	0xb2, 0xa1, 0x21, 0xa9, 0xef, 0x07, 0xb0, 0x1e, 0x79, 0x94, 0x86, 0xd6, 0xb5, 0xeb, 0x3b, 0xc1,
+	0xb5, 0x9c, 0x4e, 0x4b, 0xb8, 0x2c, 0x75, 0x5f, 0x4b, 0x15, 0xda, 0x87, 0x77, 0x24, 0x9d, 0x1d,
+	0xf8, 0x11, 0xb5, 0x63, 0xee, 0x5e, 0x51, 0x8b, 0x32, 0x16, 0xb0, 0x48, 0x8e, 0x9f, 0x06, 0x7e,
+	0x28, 0xcc, 0xad, 0xa9, 0xf5, 0x48, 0x1a, 0x53, 0x9c, 0x43, 0xb9, 0x0a, 0xca, 0x72, 0x7d, 0x4e,
+	0xd9, 0x15, 0xf1, 0xcc, 0xd2, 0x14, 0x77, 0x98, 0x58, 0x4f, 0xb5, 0x11, 0x1d, 0xc3, 0xce, 0x8d,
+	0xe5, 0x5b, 0x21, 0x65, 0x99, 0xa4, 0xc9, 0xb1, 0xd3, 0xc0, 0xef, 0xcd, 0x45, 0x73, 0x49, 0xd9,
+	0x34, 0x85, 0xa2, 0x0d, 0xda, 0x69, 0x1b, 0xfc, 0xdf, 0x3d, 0x40, 0x37, 0xc7, 0x6a, 0xf4, 0x12,
+	0x0c, 0x87, 0x7a, 0x24, 0x39, 0xde, 0xcf, 0x96, 0x9b, 0xca, 0x1b, 0x87, 0x02, 0x8b, 0x15, 0x85,
+	0xe0, 0x22, 0xbd, 0x80, 0x71, 0x33, 0xff, 0x56, 0x5c, 0x07, 0x02, 0x8b, 0x15, 0x05, 0xfa, 0x0a,
+	0xee, 0xa9, 0x53, 0x1b, 0xe9, 0xa9, 0xf4, 0xf3, 0x25, 0xd9, 0xd4, 0xc1, 0xd6, 0x83, 0x40, 0xc2,
+	0x55, 0xb3, 0x61, 0x3d, 0x6b, 0xf8, 0x5e, 0xa6, 0x9e, 0xda, 0x1f, 0xf2, 0x60, 0xc8, 0xc4, 0xa0,
+	0x6f, 0xa1, 0xdc, 0x77, 0xc7, 0xd4, 0xb1, 0xb2, 0x39, 0xfe, 0x74, 0xc9, 0x48, 0x8e, 0x05, 0x83,
+	0xe4, 0x3b, 0x59, 0xc1, 0xd0, 0x4f, 0x25, 0xf4, 0x6b, 0x28, 0xd1, 0x71, 0xa8, 0xb9, 0xd5, 0x72,
+	0xbf, 0x58, 0x92, 0xfb, 0x68, 0x1c, 0x06, 0x3e, 0xf5, 0xb9, 0x4b, 0xbc, 0xe4, 0x0b, 0x6b, 0x74,
+	0x1c, 0x2a, 0xfe, 0xdb, 0x5a, 0x68, 0xe1, 0xd6, 0x16, 0xba, 0x09, 0x1b, 0xba, 0xe2, 0x3d, 0x32,
+	0x91, 0xc3, 0x45, 0xed, 0x15, 0xc0, 0x34, 0x00, 0x64, 0xc2, 0xbd, 0x90, 0x32, 0x9b, 0xfa, 0xea,
+	0xd6, 0xcd, 0xe3, 0x44, 0x44, 0x0d, 0x78, 0x90, 0x49, 0x55, 0xda, 0x49, 0xf2, 0xb2, 0x93, 0x6c,
+	0x4e, 0xa3, 0xd6, 0x7d, 0xa4, 0xf6, 0x0d, 0x54, 0xe7, 0x17, 0xbf, 0x80, 0xfd, 0x31, 0xa0, 0x11,
+	0x25, 0xfe, 0x6b, 0xc9, 0xab, 0xc2, 0x32, 0xc3, 0xfd, 0x97, 0x1c, 0x18, 0xb2, 0x1a, 0x17, 0x30,
+	0x7e, 0x00, 0xe5, 0x01, 0x0b, 0x6d, 0x2b, 0xe2, 0x84, 0xc7, 0x51, 0x3a, 0x79, 0x81, 0x50, 0x76,
+	0xa4, 0x4e, 0xb8, 0x88, 0x6c, 0xec, 0xa9, 0x66, 0x91, 0xce, 0x60, 0xf2, 0x2d, 0xbc, 0x27, 0x7b,
+	0x44, 0xe2, 0x92, 0xb0, 0xc8, 0x4e, 0x98, 0xb8, 0x68, 0x96, 0xdb, 0x76, 0xc1, 0xb8, 0x75, 0x17,
+	0xd6, 0x01, 0xe4, 0x17, 0xd5, 0x74, 0xf7, 0xef, 0x22, 0x54, 0xe7, 0x9f, 0xc0, 0xe8, 0x17, 0xb0,
+	0xc6, 0x87, 0x2c, 0xe0, 0xdc, 0xa3, 0xba, 0x2a, 0x7f, 0xba, 0xcc, 0x13, 0xba, 0xd1, 0xd5, 0x60,
+	0x9c, 0xd2, 0xa0, 0x2e, 0x94, 0x38, 0x65, 0x23, 0x31, 0xc1, 0x26, 0x87, 0x67, 0x7f, 0x39, 0xce,
+	0x04, 0x8d, 0xa7, 0x44, 0xb5, 0xbf, 0xe5, 0x61, 0x2d, 0xf9, 0xd8, 0x82, 0xdd, 0x78, 0x0a, 0x5b,
+	0x4e, 0x70, 0xed, 0x47, 0x9c, 0x51, 0x32, 0xb2, 0x3c, 0x77, 0x24, 0xfe, 0x51, 0x09, 0xd5, 0xb6,
+	0x14, 0x30, 0x9a, 0xda, 0xce, 0x84, 0xa9, 0x19, 0x46, 0xa2, 0x22, 0xe2, 0xf0, 0x86, 0x7f, 0x41,
+	0xfa, 0x57, 0xe3, 0x70, 0xce, 0x7b, 0x1f, 0xb6, 0x93, 0x40, 0x2d, 0xd2, 0xe7, 0x94, 0xa5, 0x35,
+	0x24, 0xb6, 0x2c, 0x77, 0xb2, 0x82, 0xb7, 0x12, 0xfb, 0x81, 0x30, 0x27, 0xb7, 0xdd, 0x1e, 0x6c,
+	0xcd, 0xe1, 0x7a, 0x13, 0x4e, 0xd5, 0xfd, 0x25, 0x50, 0x68, 0x06, 0xd5, 0x14, 0x36, 0xd4, 0xce,
+	0x60, 0xfa, 0xc1, 0xf4, 0x4b, 0xea, 0xdf, 0x97, 0xf7, 0x6e, 0x0c, 0x03, 0x87, 0x41, 0xdc, 0xf3,
+	0xe8, 0x2b, 0xd1, 0x7e, 0xa6, 0x7c, 0xc7, 0x41, 0xb2, 0x06, 0x39, 0xe8, 0xcd, 0xac, 0xa1, 0xf6,
+	0x2b, 0x28, 0xa5, 0xc9, 0x5e, 0x90, 0xd4, 0x7d, 0x78, 0x27, 0xdd, 0x88, 0xb9, 0xa8, 0xd5, 0xc9,
+	0x79, 0x98, 0x9a, 0xb3, 0x41, 0xd7, 0xff, 0x93, 0x87, 0x8d, 0xa3, 0x31, 0xa7, 0xcc, 0x27, 0x5e,
+	0x87, 0xb2, 0x2b, 0xd7, 0xa6, 0x62, 0x54, 0x1b, 0x06, 0x11, 0x4f, 0x5f, 0x15, 0x25, 0x9c, 0xca,
+	0xe8, 0x18, 0x8c, 0x30, 0x60, 0x3c, 0x79, 0xee, 0x3d, 0x5d, 0x54, 0x35, 0x73, 0xbc, 0x8d, 0x4b,
+	0x79, 0x67, 0x48, 0x38, 0x7a, 0x05, 0xc0, 0x68, 0x14, 0x78, 0xb1, 0xbc, 0x21, 0x0b, 0xf2, 0xed,
+	0xb2, 0xbf, 0x0c, 0x19, 0x4e, 0xd1, 0x38, 0xc3, 0x24, 0x87, 0x70, 0x2f, 0xb2, 0x02, 0xe6, 0x0e,
+	0x92, 0x37, 0x6b, 0x51, 0xfe, 0xeb, 0x53, 0xe1, 0x5e, 0x74, 0x31, 0xd5, 0xd6, 0x5e, 0x42, 0x51,
+	0xac, 0x07, 0xa1, 0xcc, 0xf3, 0xa9, 0xa4, 0x9f, 0x40, 0x08, 0x8a, 0x61, 0x72, 0x37, 0x1a, 0x58,
+	0xfe, 0x16, 0x49, 0x91, 0xbb, 0x68, 0x07, 0x9e, 0x6e, 0xaa, 0xa9, 0x5c, 0x7f, 0x02, 0x30, 0x5d,
+	0x8e, 0x78, 0xed, 0x74, 0xba, 0xf8, 0xb4, 0xd5, 0xb5, 0x0e, 0xdb, 0x9d, 0xea, 0x8a, 0x78, 0x0e,
+	0x9d, 0x5d, 0x7c, 0x79, 0xda, 0x3a, 0x38, 0x93, 0x8a, 0x5c, 0x73, 0xed, 0x9b, 0x55, 0x15, 0x54,
+	0x6f, 0x55, 0x52, 0xfc, 0xe4, 0xff, 0x03, 0x00, 0xf5, 0xa6, 0xda, 0xf7, 0x9c, 0x15, 0x00, 0x00,
 }
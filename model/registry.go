@@ -46,31 +46,90 @@ func (k Key) String() string {
 	return fmt.Sprintf("%s/%s-%s", k.Namespace, k.Kind, k.Name)
 }
 
+// Config is a configuration object together with its key and the revision
+// of the stored object
+type Config struct {
+	Key
+	// Revision is an opaque identifier of the stored object version
+	Revision string
+	// Version is the schema version of the stored object if it is older than
+	// the schema version of the kind. The content is always upgraded to the
+	// schema version of the kind.
+	Version string
+	// Content holds the configuration object
+	Content proto.Message
+}
+
 // ConfigRegistry defines the basic API for retrieving and storing configuration
 // artifacts.
 // Object references supplied and returned from this interface should be
 // treated as read-only. Modifying them might violate thread-safety.
+//
+// Every stored object carries an opaque revision that changes on each write.
+// Updates are conditional on the revision to prevent concurrent writers from
+// silently overwriting each other's changes.
 type ConfigRegistry interface {
-	// Get retrieves a configuration element, bool indicates existence
-	Get(key Key) (proto.Message, bool)
+	// Get retrieves a configuration element, bool indicates existence,
+	// and the revision of the stored object
+	Get(key Key) (config proto.Message, exists bool, revision string)
 
-	// List returns objects for a kind in a namespace keyed by name
+	// List returns objects with their revisions for a kind in a namespace
 	// Use namespace "" to list all resources across namespaces
-	List(kind string, namespace string) (map[Key]proto.Message, error)
+	List(kind string, namespace string) ([]Config, error)
+
+	// Post creates an object in the distributed store and returns its revision.
+	// It fails with AlreadyExistsError if an object with the key exists.
+	// This implies that you might not see the effect immediately (e.g. Get
+	// might not return the object immediately).
+	// Intermittent errors might occur even though the operation succeeds.
+	Post(key Key, v proto.Message) (revision string, err error)
 
-	// Put adds an object to the distributed store.
+	// Put updates an existing object in the distributed store if the stored
+	// revision matches the given revision, and returns the new revision.
+	// It fails with NotFoundError if the object does not exist, and with
+	// ConflictError if the object has been modified since the revision.
 	// This implies that you might not see the effect immediately (e.g. Get
 	// might not return the object immediately).
 	// Intermittent errors might occur even though the operation succeeds.
-	Put(key Key, v proto.Message) error
+	Put(key Key, v proto.Message, revision string) (newRevision string, err error)
 
 	// Delete remotes an object from the distributed store.
+	// It fails with NotFoundError if the object does not exist.
 	// This implies that you might not see the effect immediately (e.g. Get
 	// might not return the object immediately).
 	// Intermittent errors might occur even though the operation succeeds.
 	Delete(key Key) error
 }
 
+// NotFoundError is returned when an object does not exist in the registry
+type NotFoundError struct {
+	Key Key
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("Item %v does not exist", e.Key)
+}
+
+// AlreadyExistsError is returned when creating an object that exists in the registry
+type AlreadyExistsError struct {
+	Key Key
+}
+
+func (e *AlreadyExistsError) Error() string {
+	return fmt.Sprintf("Item %v already exists", e.Key)
+}
+
+// ConflictError is returned when updating an object that has been modified
+// since the expected revision
+type ConflictError struct {
+	Key      Key
+	Revision string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("Item %v has been modified since revision %q", e.Key, e.Revision)
+}
+
 // KindMap defines bijection between Kind name and proto message name
 type KindMap map[string]ProtoSchema
 
@@ -80,6 +139,11 @@ type ProtoSchema struct {
 	MessageName string
 	// Validate configuration as a protobuf message
 	Validate func(o proto.Message) error
+	// Version of the schema
+	Version string
+	// Previous lists the older versions of the schema, from the oldest to the
+	// newest, that are upgraded to the schema version on read
+	Previous []SchemaVersion
 }
 
 const (
@@ -97,6 +161,14 @@ const (
 	Destination = "destination"
 	// DestinationProto message name
 	DestinationProto = "istio.proxy.v1alpha.config.Destination"
+
+	// ExternalService defines the kind for the services outside of the mesh
+	ExternalService = "external-service"
+	// ExternalServiceProto message name
+	ExternalServiceProto = "istio.proxy.v1alpha.config.ExternalService"
+
+	// V1alpha is the schema version of the istio.proxy.v1alpha.config messages
+	V1alpha = "v1alpha"
 )
 
 var (
@@ -105,14 +177,22 @@ var (
 		RouteRule: ProtoSchema{
 			MessageName: RouteRuleProto,
 			Validate:    ValidateRouteRule,
+			Version:     V1alpha,
 		},
 		IngressRule: ProtoSchema{
 			MessageName: IngressRuleProto,
 			Validate:    ValidateIngressRule,
+			Version:     V1alpha,
 		},
 		Destination: ProtoSchema{
 			MessageName: DestinationProto,
 			Validate:    ValidateDestination,
+			Version:     V1alpha,
+		},
+		ExternalService: ProtoSchema{
+			MessageName: ExternalServiceProto,
+			Validate:    ValidateExternalService,
+			Version:     V1alpha,
 		},
 	}
 )
@@ -130,7 +210,7 @@ func (i *IstioRegistry) RouteRules(namespace string) []*proxyconfig.RouteRule {
 		glog.V(2).Infof("RouteRules => %v", err)
 	}
 	for _, r := range rs {
-		if rule, ok := r.(*proxyconfig.RouteRule); ok {
+		if rule, ok := r.Content.(*proxyconfig.RouteRule); ok {
 			out = append(out, rule)
 		}
 	}
@@ -145,7 +225,7 @@ func (i *IstioRegistry) IngressRules(namespace string) []*proxyconfig.RouteRule
 		glog.V(2).Infof("IngressRules => %v", err)
 	}
 	for _, r := range rs {
-		if rule, ok := r.(*proxyconfig.RouteRule); ok {
+		if rule, ok := r.Content.(*proxyconfig.RouteRule); ok {
 			out = append(out, rule)
 		}
 	}
@@ -154,13 +234,21 @@ func (i *IstioRegistry) IngressRules(namespace string) []*proxyconfig.RouteRule
 
 // DestinationRouteRules lists all rules for a destination by precedence
 func (i *IstioRegistry) DestinationRouteRules(destination string) []*proxyconfig.RouteRule {
-	out := make([]*proxyconfig.RouteRule, 0)
-	for _, rule := range i.RouteRules("") {
-		if rule.Destination == destination {
-			out = append(out, rule)
+	rs, err := i.List(RouteRule, "")
+	if err != nil {
+		glog.V(2).Infof("DestinationRouteRules => %v", err)
+	}
+	configs := make([]Config, 0)
+	for _, r := range rs {
+		if rule, ok := r.Content.(*proxyconfig.RouteRule); ok && rule.Destination == destination {
+			configs = append(configs, r)
 		}
 	}
-	sort.Sort(RouteRulePrecedence(out))
+	sort.Sort(RouteRulePrecedence(configs))
+	out := make([]*proxyconfig.RouteRule, 0, len(configs))
+	for _, config := range configs {
+		out = append(out, config.Content.(*proxyconfig.RouteRule))
+	}
 	return out
 }
 
@@ -172,13 +260,28 @@ func (i *IstioRegistry) Destinations(namespace string) []*proxyconfig.Destinatio
 		glog.V(2).Infof("Destinations => %v", err)
 	}
 	for _, r := range rs {
-		if rule, ok := r.(*proxyconfig.Destination); ok {
+		if rule, ok := r.Content.(*proxyconfig.Destination); ok {
 			out = append(out, rule)
 		}
 	}
 	return out
 }
 
+// ExternalServices lists all external services in a namespace (or all if namespace is "")
+func (i *IstioRegistry) ExternalServices(namespace string) []*proxyconfig.ExternalService {
+	out := make([]*proxyconfig.ExternalService, 0)
+	rs, err := i.List(ExternalService, namespace)
+	if err != nil {
+		glog.V(2).Infof("ExternalServices => %v", err)
+	}
+	for _, r := range rs {
+		if svc, ok := r.Content.(*proxyconfig.ExternalService); ok {
+			out = append(out, svc)
+		}
+	}
+	return out
+}
+
 // DestinationPolicies lists all policies for a service version.
 // Policies are not inherited by tags inclusion. The policy tags must match the tags precisely.
 func (i *IstioRegistry) DestinationPolicies(destination string, tags Tags) []*proxyconfig.Destination {
@@ -192,8 +295,10 @@ func (i *IstioRegistry) DestinationPolicies(destination string, tags Tags) []*pr
 	return out
 }
 
-// RouteRulePrecedence sorts rules by precedence (high precedence first)
-type RouteRulePrecedence []*proxyconfig.RouteRule
+// RouteRulePrecedence sorts route rule configuration objects by precedence
+// (high precedence first). Rules with the same precedence are ordered by
+// namespace and name.
+type RouteRulePrecedence []Config
 
 func (s RouteRulePrecedence) Len() int {
 	return len(s)
@@ -203,7 +308,14 @@ func (s RouteRulePrecedence) Swap(i, j int) {
 	s[i], s[j] = s[j], s[i]
 }
 
-// TODO: define stable order for same precedence
 func (s RouteRulePrecedence) Less(i, j int) bool {
-	return s[i].Precedence > s[j].Precedence
+	pi := s[i].Content.(*proxyconfig.RouteRule).Precedence
+	pj := s[j].Content.(*proxyconfig.RouteRule).Precedence
+	if pi != pj {
+		return pi > pj
+	}
+	if s[i].Namespace != s[j].Namespace {
+		return s[i].Namespace < s[j].Namespace
+	}
+	return s[i].Name < s[j].Name
 }
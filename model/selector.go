@@ -0,0 +1,160 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// Tags in route rules and destination policies are selectors: besides exact
+// values, a tag value can be a set-based requirement on the tag of an instance:
+//
+//	version: in (v1, v2)    the tag has one of the values
+//	version: notin (v1)     the tag is missing or has none of the values
+//	version: exists         the tag is present
+//	version: "!exists"      the tag is missing
+//
+// An exact value "exists" can be selected with "in (exists)".
+const (
+	// SelectorIn requires one of the listed tag values
+	SelectorIn = "in"
+	// SelectorNotIn requires the tag to be missing or have none of the listed values
+	SelectorNotIn = "notin"
+	// SelectorExists requires the tag to be present
+	SelectorExists = "exists"
+	// SelectorNotExists requires the tag to be missing
+	SelectorNotExists = "!exists"
+)
+
+// selectorValuesRegexp matches the set-based requirements with a list of values.
+// The values are separated by commas, or by "+" in the canonical form used in
+// service keys.
+var selectorValuesRegexp = regexp.MustCompile(`^\s*(` + SelectorIn + `|` + SelectorNotIn + `)\s*\((.*)\)\s*$`)
+
+// requirement is a set-based condition on the value of a tag
+type requirement struct {
+	operator string
+	// values are sorted
+	values []string
+}
+
+// isSelector is true if the tag value is a set-based requirement
+func isSelector(value string) bool {
+	value = strings.TrimSpace(value)
+	return value == SelectorExists || value == SelectorNotExists || selectorValuesRegexp.MatchString(value)
+}
+
+// parseRequirement parses a set-based requirement from a tag value
+func parseRequirement(value string) (*requirement, error) {
+	value = strings.TrimSpace(value)
+	switch value {
+	case SelectorExists, SelectorNotExists:
+		return &requirement{operator: value}, nil
+	}
+	match := selectorValuesRegexp.FindStringSubmatch(value)
+	if match == nil {
+		return nil, fmt.Errorf("Invalid tag selector: %q", value)
+	}
+	out := &requirement{operator: match[1]}
+	for _, v := range strings.FieldsFunc(match[2], func(r rune) bool { return r == ',' || r == '+' }) {
+		v = strings.TrimSpace(v)
+		if v == "" || !tagRegexp.MatchString(v) {
+			return nil, fmt.Errorf("Invalid tag value %q in selector %q", v, value)
+		}
+		out.values = append(out.values, v)
+	}
+	if len(out.values) == 0 {
+		return nil, fmt.Errorf("Tag selector %q must list at least one value", value)
+	}
+	sort.Strings(out.values)
+	return out, nil
+}
+
+// matches is true if the requirement holds for the tag value of an instance
+func (r *requirement) matches(value string, present bool) bool {
+	switch r.operator {
+	case SelectorExists:
+		return present
+	case SelectorNotExists:
+		return !present
+	case SelectorIn:
+		return present && r.contains(value)
+	case SelectorNotIn:
+		return !present || !r.contains(value)
+	}
+	return false
+}
+
+func (r *requirement) contains(value string) bool {
+	i := sort.SearchStrings(r.values, value)
+	return i < len(r.values) && r.values[i] == value
+}
+
+// String returns the canonical form of the requirement, which has no
+// separators of the service key format
+func (r *requirement) String() string {
+	if len(r.values) == 0 {
+		return r.operator
+	}
+	return r.operator + "(" + strings.Join(r.values, "+") + ")"
+}
+
+// matchTag is true if the tag value of an instance satisfies the tag value of a selector
+func matchTag(selector, value string, present bool) bool {
+	if !isSelector(selector) {
+		return value == selector
+	}
+	r, err := parseRequirement(selector)
+	if err != nil {
+		return false
+	}
+	return r.matches(value, present)
+}
+
+// canonicalTagValue returns the canonical form of a tag value of a selector
+func canonicalTagValue(value string) string {
+	if !isSelector(value) {
+		return value
+	}
+	r, err := parseRequirement(value)
+	if err != nil {
+		return value
+	}
+	return r.String()
+}
+
+// ValidateSelector ensures that the tags are a well-formed selector, in which
+// values are either exact values or set-based requirements
+func (t Tags) ValidateSelector() error {
+	var errs error
+	for k, v := range t {
+		if !tagRegexp.MatchString(k) {
+			errs = multierror.Append(errs, fmt.Errorf("Invalid tag key: %q", k))
+		}
+		if isSelector(v) {
+			if _, err := parseRequirement(v); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		} else if !tagRegexp.MatchString(v) {
+			errs = multierror.Append(errs, fmt.Errorf("Invalid tag value: %q", v))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,86 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "testing"
+
+func TestTagSelectors(t *testing.T) {
+	v1 := Tags{"version": "v1", "env": "prod"}
+	v2 := Tags{"version": "v2"}
+	untagged := Tags{}
+
+	cases := []struct {
+		selector Tags
+		matches  []Tags
+		rejects  []Tags
+	}{
+		{Tags{"version": "v1"}, []Tags{v1}, []Tags{v2, untagged}},
+		{Tags{"version": "in (v1, v2)"}, []Tags{v1, v2}, []Tags{untagged}},
+		{Tags{"version": "in(v2+v3)"}, []Tags{v2}, []Tags{v1, untagged}},
+		{Tags{"version": "notin (v1)"}, []Tags{v2, untagged}, []Tags{v1}},
+		{Tags{"env": SelectorExists}, []Tags{v1}, []Tags{v2, untagged}},
+		{Tags{"env": SelectorNotExists}, []Tags{v2, untagged}, []Tags{v1}},
+		{Tags{"version": "notin (v1)", "env": SelectorNotExists}, []Tags{v2, untagged}, []Tags{v1}},
+		{Tags{"version": "in ()"}, nil, []Tags{v1, v2, untagged}},
+	}
+	for _, c := range cases {
+		for _, tags := range c.matches {
+			if !c.selector.SubsetOf(tags) {
+				t.Errorf("%v.SubsetOf(%v) => got false", c.selector, tags)
+			}
+		}
+		for _, tags := range c.rejects {
+			if c.selector.SubsetOf(tags) {
+				t.Errorf("%v.SubsetOf(%v) => got true", c.selector, tags)
+			}
+		}
+	}
+
+	if !(Tags{"version": "in (v2, v1)"}).Equals(Tags{"version": "in(v1+v2)"}) {
+		t.Error("expected equal selectors in canonical form")
+	}
+	if (Tags{"version": "in (v1)"}).Equals(Tags{"version": "v1"}) {
+		t.Error("expected a requirement to differ from an exact value")
+	}
+}
+
+func TestValidateSelector(t *testing.T) {
+	valid := []Tags{
+		nil,
+		{"version": "v1"},
+		{"version": "in (v1, v2)"},
+		{"version": "notin(v1)"},
+		{"version": SelectorExists},
+		{"version": SelectorNotExists},
+	}
+	for _, tags := range valid {
+		if err := tags.ValidateSelector(); err != nil {
+			t.Errorf("ValidateSelector(%v) => unexpected error %v", tags, err)
+		}
+	}
+
+	invalid := []Tags{
+		{"version": "in ()"},
+		{"version": "in (v1"},
+		{"version": "in (v 1)"},
+		{"version": "maybe (v1)"},
+		{"bad key": "v1"},
+	}
+	for _, tags := range invalid {
+		if err := tags.ValidateSelector(); err == nil {
+			t.Errorf("ValidateSelector(%v) => expected an error", tags)
+		}
+	}
+}
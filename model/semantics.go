@@ -0,0 +1,229 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	multierror "github.com/hashicorp/go-multierror"
+
+	proxyconfig "istio.io/manager/model/proxy/alphav1/config"
+)
+
+const (
+	// maxWeight is the sum of the weights of the destinations in a routing rule
+	maxWeight = 100
+
+	// httpTokenFmt is the format of an HTTP header name (RFC 7230)
+	httpTokenFmt = "[-!#$%&'*+.^_`|~0-9A-Za-z]+"
+)
+
+var (
+	httpTokenRex = regexp.MustCompile("^" + httpTokenFmt + "$")
+
+	// httpAttributes are the request attributes matched by the proxy in
+	// addition to the request headers
+	httpAttributes = map[string]bool{
+		"uri":       true,
+		"scheme":    true,
+		"method":    true,
+		"authority": true,
+	}
+)
+
+// ValidateRouteRuleSemantics checks a routing rule with the key against the
+// services in the service registry and the other routing rules in the
+// configuration registry and in the batch of objects applied with the rule.
+// The batch objects take the place of the stored objects with the same keys.
+// The rule checks are:
+//
+//   - the rule destination and the weighted destinations must be declared services
+//   - the weights must be non-negative and sum to 100, unless the rule has a single destination
+//   - the HTTP match keys must be request attributes or header names
+//   - the regular expressions in the match conditions must compile, and use
+//     only the syntax common to RE2 and ECMAScript (see validateRegex)
+//   - the precedence must be non-negative, and no other rule for the destination
+//     may have the same precedence and match condition
+//
+// The returned warnings describe problems that do not invalidate the rule, such
+// as weighted tags that select no instances.
+func ValidateRouteRuleSemantics(key Key, rule *proxyconfig.RouteRule,
+	services ServiceDiscovery, config ConfigRegistry, batch []Config) ([]string, error) {
+	var errs error
+	warnings := make([]string, 0)
+
+	if rule.Destination == "" {
+		errs = multierror.Append(errs, fmt.Errorf("RouteRule must have a destination service"))
+	} else if _, ok := services.GetService(rule.Destination); !ok {
+		errs = multierror.Append(errs, fmt.Errorf("Destination service %q does not exist", rule.Destination))
+	}
+
+	sum := int32(0)
+	for _, route := range rule.Route {
+		if route.Weight < 0 {
+			errs = multierror.Append(errs, fmt.Errorf("Negative weight %d for %q", route.Weight, route.Destination))
+		}
+		sum += route.Weight
+
+		destination := route.Destination
+		if destination == "" {
+			destination = rule.Destination
+		}
+		if destination == "" {
+			continue
+		}
+		svc, ok := services.GetService(destination)
+		if !ok {
+			if destination != rule.Destination {
+				errs = multierror.Append(errs, fmt.Errorf("Weighted destination service %q does not exist", destination))
+			}
+			continue
+		}
+		if len(route.Tags) > 0 {
+			tags := Tags(route.Tags)
+			if len(services.Instances(destination, svc.Ports.GetNames(), TagsList{tags}, AnyHealth)) == 0 {
+				warnings = append(warnings, fmt.Sprintf("No instances of %q match tags %v", destination, tags))
+			}
+		}
+	}
+	if len(rule.Route) > 1 || (len(rule.Route) == 1 && rule.Route[0].Weight != 0) {
+		if sum != maxWeight {
+			errs = multierror.Append(errs, fmt.Errorf("Weights must sum to %d, got %d", maxWeight, sum))
+		}
+	}
+
+	if rule.Match != nil {
+		for name, match := range rule.Match.Http {
+			if !httpAttributes[name] && !httpTokenRex.MatchString(name) {
+				errs = multierror.Append(errs, fmt.Errorf("Unknown HTTP match key %q", name))
+			}
+			if err := validateStringMatch(match); err != nil {
+				errs = multierror.Append(errs, multierror.Prefix(err, fmt.Sprintf("HTTP match %q:", name)))
+			}
+		}
+	}
+
+	if rule.Precedence < 0 {
+		errs = multierror.Append(errs, fmt.Errorf("Negative precedence %d", rule.Precedence))
+	} else if err := validatePrecedence(key, rule, config, batch); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+
+	return warnings, errs
+}
+
+// validateStringMatch checks that a match condition has a type and that
+// regular expressions compile
+func validateStringMatch(match *proxyconfig.StringMatch) error {
+	if match == nil || match.MatchType == nil {
+		return fmt.Errorf("Missing match type")
+	}
+	if m, ok := match.MatchType.(*proxyconfig.StringMatch_Regex); ok {
+		return validateRegex(m.Regex)
+	}
+	return nil
+}
+
+// validateRegex checks that a regular expression compiles with RE2 and avoids
+// the RE2 syntax that ECMAScript lacks, since the proxy matches the regular
+// expressions with ECMAScript semantics. The rejected constructs are the
+// groups other than (?:...), which include the flags and the named groups,
+// the \A, \z, \Q...\E, \p and \C escapes, the POSIX character classes, and
+// a ] at the start of a character class. The common syntax matches the same
+// strings, although ECMAScript backtracks where RE2 runs in linear time.
+func validateRegex(regex string) error {
+	if _, err := regexp.Compile(regex); err != nil {
+		return fmt.Errorf("Invalid regex %q: %v", regex, err)
+	}
+	class := false
+	for i := 0; i < len(regex); i++ {
+		switch {
+		case regex[i] == '\\' && i+1 < len(regex):
+			i++
+			if strings.IndexByte("AzQEpPC", regex[i]) >= 0 {
+				return fmt.Errorf("Unsupported escape \\%c in regex %q", regex[i], regex)
+			}
+		case class:
+			if regex[i] == ']' {
+				class = false
+			}
+		case strings.HasPrefix(regex[i:], "[[:"):
+			return fmt.Errorf("Unsupported POSIX character class in regex %q", regex)
+		case regex[i] == '[':
+			// a leading ] is a literal in RE2 but closes an empty class in ECMAScript
+			if strings.HasPrefix(regex[i+1:], "]") || strings.HasPrefix(regex[i+1:], "^]") {
+				return fmt.Errorf("Ambiguous ] at the start of a character class in regex %q, use \\]", regex)
+			}
+			class = true
+		case strings.HasPrefix(regex[i:], "(?") && !strings.HasPrefix(regex[i:], "(?:"):
+			return fmt.Errorf("Unsupported group in regex %q, only (?:...) groups are allowed", regex)
+		}
+	}
+	return nil
+}
+
+// validatePrecedence checks that no other rule for the destination has the same
+// precedence and match condition, since the order of application is unspecified
+func validatePrecedence(key Key, rule *proxyconfig.RouteRule, config ConfigRegistry, batch []Config) error {
+	var rules []Config
+	if config != nil {
+		stored, err := config.List(key.Kind, "")
+		if err != nil {
+			return err
+		}
+		rules = stored
+	}
+
+	// the batch objects replace the stored objects with the same keys
+	batched := make(map[Key]bool, len(batch))
+	for _, other := range batch {
+		batched[other.Key] = true
+	}
+	others := make([]Config, 0, len(rules)+len(batch))
+	for _, other := range rules {
+		if !batched[other.Key] {
+			others = append(others, other)
+		}
+	}
+	for _, other := range batch {
+		if other.Kind == key.Kind {
+			others = append(others, other)
+		}
+	}
+
+	var errs error
+	for _, other := range others {
+		value, ok := other.Content.(*proxyconfig.RouteRule)
+		if !ok || other.Key == key {
+			continue
+		}
+		if value.Destination == rule.Destination && value.Precedence == rule.Precedence &&
+			proto.Equal(matchOrEmpty(value.Match), matchOrEmpty(rule.Match)) {
+			errs = multierror.Append(errs, fmt.Errorf("Precedence %d collides with %v for the same match condition",
+				rule.Precedence, other.Key))
+		}
+	}
+	return errs
+}
+
+func matchOrEmpty(match *proxyconfig.MatchCondition) *proxyconfig.MatchCondition {
+	if match == nil {
+		return &proxyconfig.MatchCondition{}
+	}
+	return match
+}
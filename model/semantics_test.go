@@ -0,0 +1,247 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	proxyconfig "istio.io/manager/model/proxy/alphav1/config"
+)
+
+const (
+	hello = "hello.default.svc.cluster.local"
+	world = "world.default.svc.cluster.local"
+)
+
+// fakeDiscovery declares services with instances tagged by version
+type fakeDiscovery struct {
+	instances map[string][]Tags
+}
+
+func (d *fakeDiscovery) Services() []*Service {
+	out := make([]*Service, 0)
+	for hostname := range d.instances {
+		svc, _ := d.GetService(hostname)
+		out = append(out, svc)
+	}
+	return out
+}
+
+func (d *fakeDiscovery) GetService(hostname string) (*Service, bool) {
+	if _, ok := d.instances[hostname]; !ok {
+		return nil, false
+	}
+	return &Service{Hostname: hostname, Ports: PortList{{Name: "http", Port: 80, Protocol: ProtocolHTTP}}}, true
+}
+
+func (d *fakeDiscovery) Instances(hostname string, ports []string, tags TagsList,
+	health HealthFilter) []*ServiceInstance {
+	svc, _ := d.GetService(hostname)
+	out := make([]*ServiceInstance, 0)
+	for _, t := range d.instances[hostname] {
+		if tags.HasSubsetOf(t) {
+			out = append(out, &ServiceInstance{Service: svc, Tags: t})
+		}
+	}
+	return out
+}
+
+func (d *fakeDiscovery) HostInstances(addrs map[string]bool) []*ServiceInstance {
+	return nil
+}
+
+// fakeConfig lists a fixed set of configuration objects
+type fakeConfig struct {
+	ConfigRegistry
+	configs []Config
+}
+
+func (c *fakeConfig) List(kind, namespace string) ([]Config, error) {
+	return c.configs, nil
+}
+
+func TestValidateRouteRuleSemantics(t *testing.T) {
+	discovery := &fakeDiscovery{instances: map[string][]Tags{
+		hello: {{"version": "v1"}, {"version": "v2"}},
+		world: {{"version": "v1"}},
+	}}
+	existing := &proxyconfig.RouteRule{
+		Destination: hello,
+		Precedence:  1,
+		Match: &proxyconfig.MatchCondition{Http: map[string]*proxyconfig.StringMatch{
+			"uri": {MatchType: &proxyconfig.StringMatch_Prefix{Prefix: "/api"}},
+		}},
+	}
+	config := &fakeConfig{configs: []Config{{
+		Key:     Key{Kind: RouteRule, Name: "existing", Namespace: "default"},
+		Content: existing,
+	}}}
+	key := Key{Kind: RouteRule, Name: "rule", Namespace: "default"}
+
+	cases := []struct {
+		name     string
+		rule     *proxyconfig.RouteRule
+		errors   int
+		warnings int
+	}{
+		{
+			name: "valid",
+			rule: &proxyconfig.RouteRule{
+				Destination: hello,
+				Route: []*proxyconfig.DestinationWeight{
+					{Tags: map[string]string{"version": "v1"}, Weight: 75},
+					{Tags: map[string]string{"version": "v2"}, Weight: 25},
+				},
+				Match: &proxyconfig.MatchCondition{Http: map[string]*proxyconfig.StringMatch{
+					"uri":    {MatchType: &proxyconfig.StringMatch_Regex{Regex: "^/api/v[0-9]+"}},
+					"cookie": {MatchType: &proxyconfig.StringMatch_Exact{Exact: "user=jason"}},
+				}},
+			},
+		},
+		{
+			name: "single destination without weight",
+			rule: &proxyconfig.RouteRule{
+				Destination: hello,
+				Route:       []*proxyconfig.DestinationWeight{{Destination: world}},
+			},
+		},
+		{
+			name:   "missing destination",
+			rule:   &proxyconfig.RouteRule{Destination: "missing.default.svc.cluster.local"},
+			errors: 1,
+		},
+		{
+			name: "missing weighted destination",
+			rule: &proxyconfig.RouteRule{
+				Destination: hello,
+				Route: []*proxyconfig.DestinationWeight{
+					{Destination: "missing.default.svc.cluster.local", Weight: 50},
+					{Weight: 50},
+				},
+			},
+			errors: 1,
+		},
+		{
+			name: "bad weights",
+			rule: &proxyconfig.RouteRule{
+				Destination: hello,
+				Route: []*proxyconfig.DestinationWeight{
+					{Tags: map[string]string{"version": "v1"}, Weight: -10},
+					{Tags: map[string]string{"version": "v2"}, Weight: 90},
+				},
+			},
+			errors: 2,
+		},
+		{
+			name: "tags without instances",
+			rule: &proxyconfig.RouteRule{
+				Destination: hello,
+				Route: []*proxyconfig.DestinationWeight{
+					{Tags: map[string]string{"version": "v3"}, Weight: 100},
+				},
+			},
+			warnings: 1,
+		},
+		{
+			name: "bad match",
+			rule: &proxyconfig.RouteRule{
+				Destination: hello,
+				Match: &proxyconfig.MatchCondition{Http: map[string]*proxyconfig.StringMatch{
+					"uri":        {MatchType: &proxyconfig.StringMatch_Regex{Regex: "/api/("}},
+					"bad header": {MatchType: &proxyconfig.StringMatch_Exact{Exact: "x"}},
+					":path":      {},
+				}},
+			},
+			errors: 4,
+		},
+		{
+			name: "regex syntax without an ECMAScript equivalent",
+			rule: &proxyconfig.RouteRule{
+				Destination: hello,
+				Match: &proxyconfig.MatchCondition{Http: map[string]*proxyconfig.StringMatch{
+					"uri":       {MatchType: &proxyconfig.StringMatch_Regex{Regex: "(?i)/api"}},
+					"x-name":    {MatchType: &proxyconfig.StringMatch_Regex{Regex: "(?P<name>[a-z]+)"}},
+					"x-anchor":  {MatchType: &proxyconfig.StringMatch_Regex{Regex: "\\Aabc\\z"}},
+					"x-posix":   {MatchType: &proxyconfig.StringMatch_Regex{Regex: "[[:alpha:]]+"}},
+					"x-bracket": {MatchType: &proxyconfig.StringMatch_Regex{Regex: "[]a]"}},
+				}},
+			},
+			errors: 5,
+		},
+		{
+			name: "regex syntax common to RE2 and ECMAScript",
+			rule: &proxyconfig.RouteRule{
+				Destination: hello,
+				Match: &proxyconfig.MatchCondition{Http: map[string]*proxyconfig.StringMatch{
+					"uri":     {MatchType: &proxyconfig.StringMatch_Regex{Regex: "^/api/(?:v1|v2)/[^/]+\\.json$"}},
+					"x-class": {MatchType: &proxyconfig.StringMatch_Regex{Regex: "[(?\\]]\\(\\?\\d{2,3}"}},
+				}},
+			},
+		},
+		{
+			name:   "negative precedence",
+			rule:   &proxyconfig.RouteRule{Destination: hello, Precedence: -1},
+			errors: 1,
+		},
+		{
+			name:   "precedence collision",
+			rule:   proto.Clone(existing).(*proxyconfig.RouteRule),
+			errors: 1,
+		},
+		{
+			name: "same precedence with a different match",
+			rule: &proxyconfig.RouteRule{Destination: hello, Precedence: 1},
+		},
+	}
+
+	for _, c := range cases {
+		warnings, err := ValidateRouteRuleSemantics(key, c.rule, discovery, config, nil)
+		if len(warnings) != c.warnings {
+			t.Errorf("%s: got warnings %v, want %d", c.name, warnings, c.warnings)
+		}
+		count := 0
+		if err != nil {
+			count = len(err.(interface {
+				WrappedErrors() []error
+			}).WrappedErrors())
+		}
+		if count != c.errors {
+			t.Errorf("%s: got errors %v, want %d", c.name, err, c.errors)
+		}
+	}
+
+	// updating the existing rule does not collide with itself
+	if _, err := ValidateRouteRuleSemantics(config.configs[0].Key, existing, discovery, config, nil); err != nil {
+		t.Errorf("unexpected error updating the rule: %v", err)
+	}
+
+	// the rules of a batch collide with each other
+	rule := &proxyconfig.RouteRule{Destination: world, Precedence: 2}
+	other := Config{Key: Key{Kind: RouteRule, Name: "other", Namespace: "default"}, Content: proto.Clone(rule)}
+	batch := []Config{{Key: key, Content: rule}, other}
+	if _, err := ValidateRouteRuleSemantics(key, rule, discovery, config, batch); err == nil {
+		t.Error("expected a precedence collision with a rule in the batch")
+	}
+
+	// the batch replaces the stored rule with the same key
+	moved := proto.Clone(existing).(*proxyconfig.RouteRule)
+	moved.Precedence = 3
+	batch = []Config{{Key: config.configs[0].Key, Content: moved}}
+	if _, err := ValidateRouteRuleSemantics(key, existing, discovery, config, batch); err != nil {
+		t.Errorf("unexpected collision with a rule that the batch updates: %v", err)
+	}
+}
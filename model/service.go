@@ -26,6 +26,7 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -49,6 +50,20 @@ type Service struct {
 	// Ports is the set of network ports where the service is listening for
 	// connections
 	Ports PortList `json:"ports,omitempty"`
+
+	// External is set for services outside of the mesh, which have no
+	// instances and are reached by resolving the hostname with DNS
+	External *External `json:"external,omitempty"`
+}
+
+// External describes how the proxy connects to a service outside of the mesh
+type External struct {
+	// LogicalDNS resolves the hostname for each new connection instead of
+	// balancing the load over all the resolved addresses
+	LogicalDNS bool `json:"logical_dns,omitempty"`
+
+	// TLSOrigination encrypts the connections from the proxy to the service
+	TLSOrigination bool `json:"tls_origination,omitempty"`
 }
 
 // Port represents a network port where a service is listening for
@@ -114,6 +129,21 @@ type NetworkEndpoint struct {
 	// the service associated with this instance (e.g.,
 	// catalog.mystore.com)
 	ServicePort *Port `json:"port"`
+
+	// Locality of the network endpoint, if known
+	Locality Locality `json:"locality"`
+
+	// Weight of the network endpoint for load balancing relative to the other
+	// endpoints of the service, in the range [1, 100], or 0 for the default
+	Weight int `json:"weight,omitempty"`
+}
+
+// Locality is the failure domain of a network endpoint. The locality is
+// hierarchical: zones are within a region, and sub-zones are within a zone.
+type Locality struct {
+	Region  string `json:"region,omitempty"`
+	Zone    string `json:"zone,omitempty"`
+	Subzone string `json:"subzone,omitempty"`
 }
 
 // Tags is a non empty set of arbitrary strings. Each version of a service can
@@ -139,10 +169,47 @@ type TagsList []Tags
 //      --> NetworkEndpoint(172.16.0.2:8888), Service(catalog.myservice.com), Tag(foo=bar)
 //      --> NetworkEndpoint(172.16.0.3:8888), Service(catalog.myservice.com), Tag(kitty=cat)
 //      --> NetworkEndpoint(172.16.0.4:8888), Service(catalog.myservice.com), Tag(kitty=cat)
+//
+// Instances that fail their health or readiness checks remain in the registry
+// with an unhealthy status, so that they are visible but receive no traffic.
 type ServiceInstance struct {
 	Endpoint NetworkEndpoint `json:"endpoint,omitempty"`
 	Service  *Service        `json:"service,omitempty"`
 	Tags     Tags            `json:"tags,omitempty"`
+	Health   HealthStatus    `json:"health,omitempty"`
+}
+
+// HealthStatus of a service instance
+type HealthStatus string
+
+const (
+	// HealthUnknown is the status of the instances in registries without
+	// health checks. These instances are treated as healthy.
+	HealthUnknown HealthStatus = ""
+	// Healthy instances pass their health checks
+	Healthy HealthStatus = "healthy"
+	// Unhealthy instances fail their health checks and receive no traffic
+	Unhealthy HealthStatus = "unhealthy"
+)
+
+// IsHealthy is true unless the instance is known to be unhealthy
+func (instance *ServiceInstance) IsHealthy() bool {
+	return instance.Health != Unhealthy
+}
+
+// HealthFilter selects service instances by health status
+type HealthFilter int
+
+const (
+	// AnyHealth selects all instances, including the unhealthy ones
+	AnyHealth HealthFilter = iota
+	// HealthyOnly selects the instances that are not known to be unhealthy
+	HealthyOnly
+)
+
+// Matches is true if the instance passes the health filter
+func (f HealthFilter) Matches(instance *ServiceInstance) bool {
+	return f == AnyHealth || instance.IsHealthy()
 }
 
 // ServiceDiscovery enumerates Istio service instances.
@@ -170,23 +237,28 @@ type ServiceDiscovery interface {
 	//
 	// Similar concepts apply for calling this function with a specific
 	// port, hostname and tags.
-	Instances(hostname string, ports []string, tags TagsList) []*ServiceInstance
+	//
+	// The health filter selects whether the unhealthy instances are included.
+	Instances(hostname string, ports []string, tags TagsList, health HealthFilter) []*ServiceInstance
 
 	// HostInstances lists service instances for a given set of IPv4 addresses.
 	HostInstances(addrs map[string]bool) []*ServiceInstance
 }
 
-// SubsetOf is true if the tag has identical values for the keys
+// SubsetOf is true if the tags of an instance satisfy the tag selector:
+// exact values are identical and set-based requirements hold
 func (t Tags) SubsetOf(that Tags) bool {
 	for k, v := range t {
-		if that[k] != v {
+		value, present := that[k]
+		if !matchTag(v, value, present) {
 			return false
 		}
 	}
 	return true
 }
 
-// Equals returns true if the tags are identical
+// Equals returns true if the tags are identical.
+// Set-based requirements are compared in their canonical form.
 func (t Tags) Equals(that Tags) bool {
 	if t == nil {
 		return that == nil
@@ -194,7 +266,17 @@ func (t Tags) Equals(that Tags) bool {
 	if that == nil {
 		return t == nil
 	}
-	return t.SubsetOf(that) && that.SubsetOf(t)
+	return t.sameValues(that) && that.sameValues(t)
+}
+
+// sameValues is true if the tags have the same values for the keys
+func (t Tags) sameValues(that Tags) bool {
+	for k, v := range t {
+		if canonicalTagValue(that[k]) != canonicalTagValue(v) {
+			return false
+		}
+	}
+	return true
 }
 
 // HasSubsetOf returns true if the input tags are a super set of one tags in a
@@ -236,61 +318,130 @@ func (s *Service) Key(port *Port, tag Tags) string {
 	return ServiceKey(s.Hostname, PortList{port}, TagsList{tag})
 }
 
-// ServiceKey generates a service key for a collection of ports and tags
+// Service keys identify the instances of a service for a collection of ports
+// and tags, e.g. in the cluster names and service discovery requests of the
+// proxy. The key format is versioned:
+//
+//	v1@hostname:port1,port2:key1=value1,key2=value2;key3=value3
+//
+// Hostnames, port names, tag keys and tag values are escaped, so that the keys
+// round-trip and can be used in URL paths: every byte other than letters,
+// digits and "-._()+!*" is written as "~" followed by two hexadecimal digits.
+// Tag sets are sorted, and tags in a set are sorted by key.
+//
+// The legacy format without a version, such as "hostname:http:version=v1",
+// is still parsed, but it does not round-trip all tag values.
+const (
+	// ServiceKeyVersion is the version of the service key format
+	ServiceKeyVersion = "v1"
+
+	serviceKeyPrefix = ServiceKeyVersion + "@"
+	serviceKeyEscape = '~'
+)
+
+// ServiceKey generates a service key for a collection of ports and tags.
+// Empty tag sets select all instances and are omitted.
 func ServiceKey(hostname string, servicePorts PortList, serviceTags TagsList) string {
-	// example: name.namespace:http:env=prod;env=test,version=my-v1
 	var buffer bytes.Buffer
-	buffer.WriteString(hostname)
-	np := len(servicePorts)
-	nt := len(serviceTags)
+	buffer.WriteString(serviceKeyPrefix)
+	buffer.WriteString(escapeKeyPart(hostname))
+	buffer.WriteString(":")
 
-	if nt == 1 && serviceTags[0] == nil {
-		nt = 0
+	ports := make([]string, 0, len(servicePorts))
+	for _, port := range servicePorts {
+		ports = append(ports, escapeKeyPart(port.Name))
 	}
-
-	if np == 0 && nt == 0 {
-		return buffer.String()
-	} else if np == 1 && nt == 0 && servicePorts[0].Name == "" {
-		return buffer.String()
-	} else {
-		buffer.WriteString(":")
+	sort.Strings(ports)
+	buffer.WriteString(strings.Join(ports, ","))
+	buffer.WriteString(":")
+
+	sets := make([]string, 0, len(serviceTags))
+	for _, tags := range serviceTags {
+		if len(tags) == 0 {
+			// an empty tag set matches all instances
+			sets = nil
+			break
+		}
+		pairs := make([]string, 0, len(tags))
+		for k, v := range tags {
+			pairs = append(pairs, escapeKeyPart(k)+"="+escapeKeyPart(canonicalTagValue(v)))
+		}
+		sort.Strings(pairs)
+		sets = append(sets, strings.Join(pairs, ","))
 	}
+	sort.Strings(sets)
+	buffer.WriteString(strings.Join(sets, ";"))
+	return buffer.String()
+}
 
-	if np > 0 {
-		ports := make([]string, np)
-		for i := 0; i < np; i++ {
-			ports[i] = servicePorts[i].Name
-		}
-		sort.Strings(ports)
-		for i := 0; i < np; i++ {
-			if i > 0 {
-				buffer.WriteString(",")
-			}
-			buffer.WriteString(ports[i])
+// ParseServiceKey is the inverse of the ServiceKey function. It parses both the
+// versioned and the legacy formats, and fails on malformed keys.
+func ParseServiceKey(s string) (hostname string, ports PortList, tags TagsList, err error) {
+	if !strings.HasPrefix(s, serviceKeyPrefix) {
+		if i := strings.Index(s, "@"); i >= 0 {
+			return "", nil, nil, fmt.Errorf("Unsupported service key version %q", s[:i])
 		}
+		return parseLegacyServiceKey(s)
 	}
 
-	if nt > 0 {
-		buffer.WriteString(":")
-		tags := make([]string, nt)
-		for i := 0; i < nt; i++ {
-			tags[i] = serviceTags[i].String()
+	parts := strings.Split(strings.TrimPrefix(s, serviceKeyPrefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, fmt.Errorf("Service key %q must have a hostname, ports, and tags", s)
+	}
+	if hostname, err = unescapeKeyPart(parts[0]); err != nil {
+		return "", nil, nil, err
+	}
+	if hostname == "" {
+		return "", nil, nil, fmt.Errorf("Service key %q has an empty hostname", s)
+	}
+
+	for _, name := range strings.Split(parts[1], ",") {
+		if name, err = unescapeKeyPart(name); err != nil {
+			return "", nil, nil, err
 		}
-		sort.Strings(tags)
-		for i := 0; i < nt; i++ {
-			if i > 0 {
-				buffer.WriteString(";")
+		ports = append(ports, &Port{Name: name})
+	}
+
+	if parts[2] == "" {
+		return hostname, ports, nil, nil
+	}
+	for _, set := range strings.Split(parts[2], ";") {
+		tag := make(Tags)
+		for _, pair := range strings.Split(set, ",") {
+			kv := strings.Split(pair, "=")
+			if len(kv) != 2 {
+				return "", nil, nil, fmt.Errorf("Malformed tag %q in service key %q", pair, s)
+			}
+			var k, v string
+			if k, err = unescapeKeyPart(kv[0]); err != nil {
+				return "", nil, nil, err
+			}
+			if v, err = unescapeKeyPart(kv[1]); err != nil {
+				return "", nil, nil, err
 			}
-			buffer.WriteString(tags[i])
+			if k == "" {
+				return "", nil, nil, fmt.Errorf("Empty tag key in service key %q", s)
+			}
+			if _, exists := tag[k]; exists {
+				return "", nil, nil, fmt.Errorf("Duplicate tag key %q in service key %q", k, s)
+			}
+			tag[k] = v
 		}
+		tags = append(tags, tag)
 	}
-	return buffer.String()
+	return hostname, ports, tags, nil
 }
 
-// ParseServiceKey is the inverse of the Service.String() method
-func ParseServiceKey(s string) (hostname string, ports PortList, tags TagsList) {
+// parseLegacyServiceKey parses the service key format without a version
+func parseLegacyServiceKey(s string) (hostname string, ports PortList, tags TagsList, err error) {
 	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return "", nil, nil, fmt.Errorf("Service key %q has too many parts", s)
+	}
 	hostname = parts[0]
+	if hostname == "" {
+		return "", nil, nil, fmt.Errorf("Service key %q has an empty hostname", s)
+	}
 
 	var names []string
 	if len(parts) > 1 {
@@ -311,11 +462,62 @@ func ParseServiceKey(s string) (hostname string, ports PortList, tags TagsList)
 	return
 }
 
+// isKeyByte is true for the bytes written without escaping in service keys
+func isKeyByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._()+!*", c) >= 0
+}
+
+// isUpperHex is true for the hex digits written in service key escapes
+func isUpperHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'A' <= c && c <= 'F'
+}
+
+func escapeKeyPart(s string) string {
+	var buffer bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; isKeyByte(c) {
+			buffer.WriteByte(c)
+		} else {
+			fmt.Fprintf(&buffer, "%c%02X", serviceKeyEscape, c)
+		}
+	}
+	return buffer.String()
+}
+
+func unescapeKeyPart(s string) (string, error) {
+	var buffer bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == serviceKeyEscape:
+			if i+2 >= len(s) {
+				return "", fmt.Errorf("Truncated escape sequence in %q", s)
+			}
+			// a key has a single encoding: uppercase hex digits and no escapes
+			// of the bytes written as is
+			if !isUpperHex(s[i+1]) || !isUpperHex(s[i+2]) {
+				return "", fmt.Errorf("Invalid escape sequence in %q", s)
+			}
+			b, _ := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			if isKeyByte(byte(b)) {
+				return "", fmt.Errorf("Unnecessary escape sequence in %q", s)
+			}
+			buffer.WriteByte(byte(b))
+			i += 2
+		case isKeyByte(c):
+			buffer.WriteByte(c)
+		default:
+			return "", fmt.Errorf("Unescaped character %q in %q", c, s)
+		}
+	}
+	return buffer.String(), nil
+}
+
 func (t Tags) String() string {
 	labels := make([]string, 0)
 	for k, v := range t {
 		if len(v) > 0 {
-			labels = append(labels, fmt.Sprintf("%s=%s", k, v))
+			labels = append(labels, fmt.Sprintf("%s=%s", k, canonicalTagValue(v)))
 		} else {
 			labels = append(labels, k)
 		}
@@ -14,41 +14,86 @@
 
 package model
 
-import "testing"
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
 
 var validServiceKeys = map[string]struct {
 	service Service
 	tags    TagsList
 }{
-	"example-service1.default:grpc,http:a=b,c=d;e=f": {
+	"v1@example-service1.default:grpc,http:a=b,c=d;e=f": {
 		service: Service{
 			Hostname: "example-service1.default",
 			Ports:    []*Port{{Name: "http"}, {Name: "grpc"}}},
 		tags: TagsList{{"e": "f"}, {"c": "d", "a": "b"}}},
-	"my-service": {
+	"v1@my-service::": {
 		service: Service{
 			Hostname: "my-service",
 			Ports:    []*Port{{Name: ""}}}},
-	"svc.ns": {
+	"v1@svc.ns::": {
 		service: Service{
 			Hostname: "svc.ns",
 			Ports:    []*Port{{Name: ""}}}},
-	"svc::istio.io/my_tag-v1.test=my_value-v2.value": {
+	"v1@svc::istio.io~2Fmy_tag-v1.test=my_value-v2.value": {
 		service: Service{
 			Hostname: "svc",
 			Ports:    []*Port{{Name: ""}}},
 		tags: TagsList{{"istio.io/my_tag-v1.test": "my_value-v2.value"}}},
-	"svc:test:prod": {
+	"v1@svc:test:prod=": {
 		service: Service{
 			Hostname: "svc",
 			Ports:    []*Port{{Name: "test"}}},
 		tags: TagsList{{"prod": ""}}},
-	"svc.default.svc.cluster.local:http-test": {
+	"v1@svc:test:a=,b=;b=": {
+		service: Service{
+			Hostname: "svc",
+			Ports:    []*Port{{Name: "test"}}},
+		tags: TagsList{{"b": ""}, {"a": "", "b": ""}}},
+	"v1@svc:http:env=!exists,version=notin(v1+v2)": {
+		service: Service{
+			Hostname: "svc",
+			Ports:    []*Port{{Name: "http"}}},
+		tags: TagsList{{"version": "notin (v2, v1)", "env": "!exists"}}},
+	"v1@svc.default.svc.cluster.local:http-test:": {
 		service: Service{
 			Hostname: "svc.default.svc.cluster.local",
 			Ports:    []*Port{{Name: "http-test"}}}},
 }
 
+var legacyServiceKeys = map[string]struct {
+	hostname string
+	ports    int
+	tags     TagsList
+}{
+	"example-service1.default:grpc,http:a=b,c=d;e=f": {
+		"example-service1.default", 2, TagsList{{"e": "f"}, {"c": "d", "a": "b"}}},
+	"my-service":    {"my-service", 1, nil},
+	"svc:test:prod": {"svc", 1, TagsList{{"prod": ""}}},
+	"svc.default.svc.cluster.local:http-test": {"svc.default.svc.cluster.local", 1, nil},
+}
+
+var invalidServiceKeys = []string{
+	"",
+	"v2@svc::",
+	"v1@svc:http",
+	"v1@:http:",
+	"v1@svc:http:version",
+	"v1@svc:http:version=v1=v2",
+	"v1@svc:http:=v1",
+	"v1@svc:http:version=v1,version=v2",
+	"v1@svc:http:version=v1;",
+	"v1@svc:http:istio.io/tag=v1",
+	"v1@svc:http:tag=~2",
+	"v1@svc:http:tag=~ZZ",
+	"v1@svc:http:tag=~2f",
+	"v1@svc:http:tag=~61",
+	"v1@svc:http:tag=~+F",
+	"svc:http:version=v1:extra",
+}
+
 func TestServiceString(t *testing.T) {
 	for s, svc := range validServiceKeys {
 		if err := svc.service.Validate(); err != nil {
@@ -58,7 +103,10 @@ func TestServiceString(t *testing.T) {
 		if s1 != s {
 			t.Errorf("ServiceKey => Got %s, expected %s", s1, s)
 		}
-		hostname, ports, tags := ParseServiceKey(s)
+		hostname, ports, tags, err := ParseServiceKey(s)
+		if err != nil {
+			t.Errorf("ParseServiceKey(%s) => unexpected error %v", s, err)
+		}
 		if hostname != svc.service.Hostname {
 			t.Errorf("ParseServiceKey => Got %s, expected %s for %s", hostname, svc.service.Hostname, s)
 		}
@@ -71,6 +119,41 @@ func TestServiceString(t *testing.T) {
 	}
 }
 
+func TestParseLegacyServiceKey(t *testing.T) {
+	for s, want := range legacyServiceKeys {
+		hostname, ports, tags, err := ParseServiceKey(s)
+		if err != nil {
+			t.Errorf("ParseServiceKey(%s) => unexpected error %v", s, err)
+		}
+		if hostname != want.hostname || len(ports) != want.ports || !compareTags(tags, want.tags) {
+			t.Errorf("ParseServiceKey(%s) => Got %s, %d ports, %v", s, hostname, len(ports), tags)
+		}
+	}
+}
+
+func TestParseInvalidServiceKey(t *testing.T) {
+	for _, s := range invalidServiceKeys {
+		if _, _, _, err := ParseServiceKey(s); err == nil {
+			t.Errorf("ParseServiceKey(%q) => expected an error", s)
+		}
+	}
+}
+
+func TestServiceKeyEscaping(t *testing.T) {
+	tags := TagsList{{"a:b": "c,d", "e;f": "g=h", "i~j": "k@l/m"}}
+	key := ServiceKey("svc", PortList{{Name: "http"}}, tags)
+	if strings.Count(key, "@") != 1 || strings.Contains(key, "/") {
+		t.Errorf("ServiceKey => %s is not escaped", key)
+	}
+	_, _, out, err := ParseServiceKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || !reflect.DeepEqual(out[0], tags[0]) {
+		t.Errorf("ParseServiceKey(%s) => Got %v, expected %v", key, out, tags)
+	}
+}
+
 // compare two slices of strings as sets
 func compare(a, b []string) bool {
 	ma := make(map[string]bool)
@@ -155,3 +238,17 @@ func TestTags(t *testing.T) {
 	}
 
 }
+
+func TestHealthFilter(t *testing.T) {
+	healthy := &ServiceInstance{Endpoint: NetworkEndpoint{Address: "10.0.0.1"}, Health: Healthy}
+	unknown := &ServiceInstance{Endpoint: NetworkEndpoint{Address: "10.0.0.2"}}
+	unhealthy := &ServiceInstance{Endpoint: NetworkEndpoint{Address: "10.0.0.3"}, Health: Unhealthy}
+	for _, instance := range []*ServiceInstance{healthy, unknown, unhealthy} {
+		if !AnyHealth.Matches(instance) {
+			t.Errorf("AnyHealth.Matches(%v) => got false", instance.Health)
+		}
+		if HealthyOnly.Matches(instance) != (instance != unhealthy) {
+			t.Errorf("HealthyOnly.Matches(%v) => got %t", instance.Health, !instance.IsHealthy())
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Snapshot is an immutable view of the service registry and the configuration
+// registry. Every registry is read once when the snapshot is taken, so that
+// repeated reads of the snapshot return the same view while the registries
+// keep changing underneath.
+//
+// The registries are read one after another, and the snapshot is not atomic
+// across them: a snapshot may observe a configuration change but not a
+// concurrent service change. Controller events that follow the snapshot
+// trigger another one.
+//
+// A snapshot captures the service catalog but only the instances co-located
+// with a set of proxy addresses, since reading the instances of every service
+// costs as much as the whole endpoint catalog while a proxy configuration
+// only depends on its own host instances. The instance queries of the
+// snapshot are answered from the captured host instances.
+//
+// Snapshot implements ServiceDiscovery and a read-only ConfigRegistry.
+type Snapshot struct {
+	// Version increases monotonically with every snapshot of a snapshotter
+	Version uint64
+
+	services  []*Service
+	instances []*ServiceInstance
+	configs   map[string][]Config
+	errors    map[string]error
+}
+
+// Snapshotter takes versioned snapshots of a service registry and a
+// configuration registry
+type Snapshotter struct {
+	discovery ServiceDiscovery
+	config    ConfigRegistry
+	kinds     KindMap
+
+	// mu serializes the snapshots so that the versions follow the reads
+	mu      sync.Mutex
+	version uint64
+}
+
+// NewSnapshotter creates a snapshotter for the service discovery and the
+// configuration kinds in the configuration registry
+func NewSnapshotter(discovery ServiceDiscovery, config ConfigRegistry, kinds KindMap) *Snapshotter {
+	return &Snapshotter{
+		discovery: discovery,
+		config:    config,
+		kinds:     kinds,
+	}
+}
+
+// Snapshot reads the registries and returns a snapshot with the next version.
+// The snapshot holds the service instances co-located with the addresses,
+// and no instances if the addresses are empty.
+func (s *Snapshotter) Snapshot(addrs map[string]bool) *Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+
+	out := &Snapshot{
+		Version:  s.version,
+		services: s.discovery.Services(),
+		configs:  make(map[string][]Config, len(s.kinds)),
+		errors:   make(map[string]error),
+	}
+	if len(addrs) > 0 {
+		out.instances = s.discovery.HostInstances(addrs)
+	}
+	for kind := range s.kinds {
+		configs, err := s.config.List(kind, "")
+		if err != nil {
+			out.errors[kind] = err
+			continue
+		}
+		out.configs[kind] = configs
+	}
+	return out
+}
+
+// Services implements a service catalog operation
+func (s *Snapshot) Services() []*Service {
+	out := make([]*Service, len(s.services))
+	copy(out, s.services)
+	return out
+}
+
+// GetService implements a service catalog operation
+func (s *Snapshot) GetService(hostname string) (*Service, bool) {
+	for _, svc := range s.services {
+		if svc.Hostname == hostname {
+			return svc, true
+		}
+	}
+	return nil, false
+}
+
+// Instances implements a service catalog operation.
+// Only the captured host instances are returned.
+func (s *Snapshot) Instances(hostname string, ports []string, tagsList TagsList,
+	health HealthFilter) []*ServiceInstance {
+	names := make(map[string]bool, len(ports))
+	for _, port := range ports {
+		names[port] = true
+	}
+	out := make([]*ServiceInstance, 0)
+	for _, instance := range s.instances {
+		if instance.Service.Hostname == hostname && names[instance.Endpoint.ServicePort.Name] &&
+			tagsList.HasSubsetOf(instance.Tags) && health.Matches(instance) {
+			out = append(out, instance)
+		}
+	}
+	return out
+}
+
+// HostInstances implements a service catalog operation.
+// Only the captured host instances are returned.
+func (s *Snapshot) HostInstances(addrs map[string]bool) []*ServiceInstance {
+	out := make([]*ServiceInstance, 0)
+	for _, instance := range s.instances {
+		if addrs[instance.Endpoint.Address] {
+			out = append(out, instance)
+		}
+	}
+	return out
+}
+
+// Get implements a registry operation
+func (s *Snapshot) Get(key Key) (proto.Message, bool, string) {
+	for _, config := range s.configs[key.Kind] {
+		if config.Key == key {
+			return config.Content, true, config.Revision
+		}
+	}
+	return nil, false, ""
+}
+
+// List implements a registry operation
+func (s *Snapshot) List(kind, namespace string) ([]Config, error) {
+	if err, exists := s.errors[kind]; exists {
+		return nil, err
+	}
+	configs, exists := s.configs[kind]
+	if !exists {
+		return nil, fmt.Errorf("Missing kind %q in snapshot version %d", kind, s.Version)
+	}
+	out := make([]Config, 0, len(configs))
+	for _, config := range configs {
+		if namespace == "" || config.Namespace == namespace {
+			out = append(out, config)
+		}
+	}
+	return out, nil
+}
+
+// Post fails since a snapshot is read-only
+func (s *Snapshot) Post(key Key, v proto.Message) (string, error) {
+	return "", fmt.Errorf("Snapshot version %d is read-only", s.Version)
+}
+
+// Put fails since a snapshot is read-only
+func (s *Snapshot) Put(key Key, v proto.Message, revision string) (string, error) {
+	return "", fmt.Errorf("Snapshot version %d is read-only", s.Version)
+}
+
+// Delete fails since a snapshot is read-only
+func (s *Snapshot) Delete(key Key) error {
+	return fmt.Errorf("Snapshot version %d is read-only", s.Version)
+}
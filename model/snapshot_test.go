@@ -0,0 +1,170 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	proxyconfig "istio.io/manager/model/proxy/alphav1/config"
+)
+
+// snapshotDiscovery is a service registry with a single service
+type snapshotDiscovery struct {
+	service   *Service
+	instances []*ServiceInstance
+
+	// instanceReads counts the queries for the instances of a service
+	instanceReads int
+}
+
+func (d *snapshotDiscovery) Services() []*Service {
+	return []*Service{d.service}
+}
+
+func (d *snapshotDiscovery) GetService(hostname string) (*Service, bool) {
+	return d.service, hostname == d.service.Hostname
+}
+
+func (d *snapshotDiscovery) Instances(hostname string, ports []string, tags TagsList,
+	health HealthFilter) []*ServiceInstance {
+	d.instanceReads++
+	if hostname != d.service.Hostname {
+		return nil
+	}
+	return d.instances
+}
+
+func (d *snapshotDiscovery) HostInstances(addrs map[string]bool) []*ServiceInstance {
+	out := make([]*ServiceInstance, 0)
+	for _, instance := range d.instances {
+		if addrs[instance.Endpoint.Address] {
+			out = append(out, instance)
+		}
+	}
+	return out
+}
+
+// snapshotRegistry is a configuration registry with a single kind
+type snapshotRegistry struct {
+	configs []Config
+}
+
+func (r *snapshotRegistry) Get(key Key) (proto.Message, bool, string) {
+	return nil, false, ""
+}
+
+func (r *snapshotRegistry) List(kind, namespace string) ([]Config, error) {
+	if kind != RouteRule {
+		return nil, fmt.Errorf("Missing kind %q", kind)
+	}
+	return r.configs, nil
+}
+
+func (r *snapshotRegistry) Post(key Key, v proto.Message) (string, error) {
+	return "", nil
+}
+
+func (r *snapshotRegistry) Put(key Key, v proto.Message, revision string) (string, error) {
+	return "", nil
+}
+
+func (r *snapshotRegistry) Delete(key Key) error {
+	return nil
+}
+
+func TestSnapshot(t *testing.T) {
+	port := &Port{Name: "http", Port: 80, Protocol: ProtocolHTTP}
+	svc := &Service{Hostname: "hello.default.svc.cluster.local", Ports: PortList{port}}
+	instance := func(ip string, tags Tags) *ServiceInstance {
+		return &ServiceInstance{
+			Endpoint: NetworkEndpoint{Address: ip, Port: 8080, ServicePort: port},
+			Service:  svc,
+			Tags:     tags,
+		}
+	}
+	v1 := instance("10.0.0.1", Tags{"version": "v1"})
+	v2 := instance("10.0.0.2", Tags{"version": "v2"})
+	v3 := instance("10.0.0.3", Tags{"version": "v2"})
+	rule := Config{
+		Key:     Key{Kind: RouteRule, Name: "rule", Namespace: "default"},
+		Content: &proxyconfig.RouteRule{Destination: svc.Hostname},
+	}
+
+	discovery := &snapshotDiscovery{service: svc, instances: []*ServiceInstance{v1, v2, v3}}
+	registry := &snapshotRegistry{configs: []Config{rule}}
+	snapshotter := NewSnapshotter(discovery, registry, KindMap{RouteRule: IstioConfig[RouteRule]})
+	snapshot := snapshotter.Snapshot(map[string]bool{"10.0.0.1": true, "10.0.0.2": true})
+	if discovery.instanceReads != 0 {
+		t.Errorf("Snapshot() => read the instances of %d services, want none", discovery.instanceReads)
+	}
+
+	// the registries change after the snapshot
+	discovery.instances = nil
+	registry.configs = nil
+
+	if snapshot.Version != 1 {
+		t.Errorf("Version => got %d, want 1", snapshot.Version)
+	}
+	next := snapshotter.Snapshot(nil)
+	if next.Version != 2 {
+		t.Errorf("Version => got %d, want 2", next.Version)
+	}
+	if out := next.HostInstances(map[string]bool{"10.0.0.1": true}); len(out) != 0 {
+		t.Errorf("HostInstances() => got %v, want none without addresses", out)
+	}
+
+	if out := snapshot.Services(); !reflect.DeepEqual(out, []*Service{svc}) {
+		t.Errorf("Services() => got %v", out)
+	}
+	if out, exists := snapshot.GetService(svc.Hostname); !exists || out != svc {
+		t.Errorf("GetService() => got %v", out)
+	}
+	if _, exists := snapshot.GetService("missing"); exists {
+		t.Error("GetService(missing) => got a service")
+	}
+	out := snapshot.Instances(svc.Hostname, []string{"http"}, TagsList{{"version": "v2"}}, AnyHealth)
+	if !reflect.DeepEqual(out, []*ServiceInstance{v2}) {
+		t.Errorf("Instances() => got %v, want only the host instance %v", out, v2)
+	}
+	if out := snapshot.HostInstances(map[string]bool{"10.0.0.1": true, "10.0.0.3": true}); !reflect.DeepEqual(out,
+		[]*ServiceInstance{v1}) {
+		t.Errorf("HostInstances() => got %v, want %v", out, v1)
+	}
+
+	if out, err := snapshot.List(RouteRule, "default"); err != nil || !reflect.DeepEqual(out, []Config{rule}) {
+		t.Errorf("List() => got %v, %v", out, err)
+	}
+	if out, err := snapshot.List(RouteRule, "other"); err != nil || len(out) != 0 {
+		t.Errorf("List(other) => got %v, %v", out, err)
+	}
+	if _, err := snapshot.List(Destination, ""); err == nil {
+		t.Error("List(destination) => expected an error for a kind outside of the snapshot")
+	}
+	if out, exists, _ := snapshot.Get(rule.Key); !exists || out != rule.Content {
+		t.Errorf("Get() => got %v", out)
+	}
+	if err := snapshot.Delete(rule.Key); err == nil {
+		t.Error("Delete() => expected an error for a read-only snapshot")
+	}
+
+	istio := &IstioRegistry{ConfigRegistry: snapshot}
+	if out := istio.DestinationRouteRules(svc.Hostname); len(out) != 1 {
+		t.Errorf("DestinationRouteRules() => got %v", out)
+	}
+}
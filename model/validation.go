@@ -16,10 +16,15 @@ package model
 
 import (
 	"fmt"
+	"net"
+	"net/http"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
 
 	multierror "github.com/hashicorp/go-multierror"
 
@@ -33,9 +38,23 @@ const (
 	qualifiedNameFmt string = "[-A-Za-z0-9_./]*"
 )
 
+const (
+	// TimeoutOverrideHeader is the only request header that the proxy honors
+	// to override the timeout of a destination policy, in milliseconds
+	TimeoutOverrideHeader = "x-envoy-upstream-rq-timeout-ms"
+
+	// RetriesOverrideHeader is the only request header that the proxy honors
+	// to override the number of retries of a destination policy
+	RetriesOverrideHeader = "x-envoy-max-retries"
+)
+
 var (
 	dns1123LabelRex = regexp.MustCompile("^" + dns1123LabelFmt + "$")
 	tagRegexp       = regexp.MustCompile("^" + qualifiedNameFmt + "$")
+
+	// customPolicies validate the custom policy payloads by type URL
+	customPolicies      = make(map[string]func(*any.Any) error)
+	customPoliciesMutex sync.RWMutex
 )
 
 // IsDNS1123Label tests for a string that conforms to the definition of a label in
@@ -69,6 +88,20 @@ func (km KindMap) Validate() error {
 		if proto.MessageType(v.MessageName) == nil {
 			errs = multierror.Append(errs, fmt.Errorf("Cannot find proto message type: %q", v.MessageName))
 		}
+		versions := map[string]bool{v.Version: true}
+		for _, previous := range v.Previous {
+			if previous.Version == "" || versions[previous.Version] {
+				errs = multierror.Append(errs, fmt.Errorf("Invalid schema version %q of %q", previous.Version, k))
+			}
+			versions[previous.Version] = true
+			if proto.MessageType(previous.MessageName) == nil {
+				errs = multierror.Append(errs, fmt.Errorf("Cannot find proto message type: %q", previous.MessageName))
+			}
+			if previous.Upgrade == nil {
+				errs = multierror.Append(errs, fmt.Errorf("Missing upgrade from schema version %q of %q",
+					previous.Version, k))
+			}
+		}
 	}
 	return errs
 }
@@ -148,6 +181,14 @@ func (s *Service) Validate() error {
 	return errs
 }
 
+// ValidateEndpointWeight checks the load balancing weight of a network endpoint
+func ValidateEndpointWeight(weight int) error {
+	if weight < 1 || weight > 100 {
+		return fmt.Errorf("Endpoint weight %d must be in range [1, 100]", weight)
+	}
+	return nil
+}
+
 // Validate ensures tag is well-formed
 func (t Tags) Validate() error {
 	var errs error
@@ -174,6 +215,57 @@ func ValidateRouteRule(msg proto.Message) error {
 	if value.GetDestination() == "" {
 		return fmt.Errorf("RouteRule must have a destination service")
 	}
+
+	var errs error
+	for _, route := range value.Route {
+		if err := Tags(route.Tags).ValidateSelector(); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "route:"))
+		}
+	}
+	if err := Tags(value.GetMatch().GetSourceTags()).ValidateSelector(); err != nil {
+		errs = multierror.Append(errs, multierror.Prefix(err, "source_tags:"))
+	}
+	if tcp := value.GetMatch().GetTcp(); tcp != nil {
+		if err := validateL4Match(tcp); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "tcp:"))
+		}
+	}
+	if udp := value.GetMatch().GetUdp(); udp != nil {
+		if err := validateL4Match(udp); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "udp:"))
+		}
+	}
+	return errs
+}
+
+// validateL4Match checks that the subnets are IP addresses with optional prefix lengths
+func validateL4Match(match *proxyconfig.L4MatchAttributes) error {
+	var errs error
+	for _, subnet := range match.SourceSubnet {
+		if err := ValidateSubnet(subnet); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "source_subnet:"))
+		}
+	}
+	for _, subnet := range match.DestinationSubnet {
+		if err := ValidateSubnet(subnet); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "destination_subnet:"))
+		}
+	}
+	return errs
+}
+
+// ValidateSubnet checks that a subnet is an IPv4 or IPv6 address in the
+// a.b.c.d/xx form or just a.b.c.d
+func ValidateSubnet(subnet string) error {
+	if strings.Contains(subnet, "/") {
+		if _, _, err := net.ParseCIDR(subnet); err != nil {
+			return fmt.Errorf("Invalid subnet %q", subnet)
+		}
+		return nil
+	}
+	if net.ParseIP(subnet) == nil {
+		return fmt.Errorf("Invalid IP address %q", subnet)
+	}
 	return nil
 }
 
@@ -192,5 +284,265 @@ func ValidateDestination(msg proto.Message) error {
 	if value.GetDestination() == "" {
 		return fmt.Errorf("Destination should have a valid service name in its destination field")
 	}
+
+	var errs error
+	if err := Tags(value.Tags).ValidateSelector(); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	if value.ZoneAwareRouting && len(value.Tags) > 0 {
+		errs = multierror.Append(errs,
+			fmt.Errorf("zone_aware_routing: Zone aware routing applies to all the destinations of a proxy "+
+				"and cannot select tags"))
+	}
+	if value.LoadBalancing != nil {
+		if custom, ok := value.LoadBalancing.LbPolicy.(*proxyconfig.LoadBalancing_Custom); ok {
+			if err := validateCustomPolicy(custom.Custom); err != nil {
+				errs = multierror.Append(errs, multierror.Prefix(err, "load_balancing:"))
+			}
+		}
+	}
+	if value.CircuitBreaker != nil {
+		if err := validateCircuitBreaker(value.CircuitBreaker); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "circuit_breaker:"))
+		}
+	}
+	if value.HttpTimeout != nil {
+		if err := validateHTTPTimeout(value.HttpTimeout); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "http_timeout:"))
+		}
+	}
+	if value.HttpRetry != nil {
+		if err := validateHTTPRetry(value.HttpRetry); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "http_retry:"))
+		}
+	}
+	if value.HttpFault != nil {
+		if err := validateHTTPFault(value.HttpFault); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "http_fault:"))
+		}
+	}
+	if value.L4Fault != nil {
+		if err := validateL4Fault(value.L4Fault); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "l4_fault:"))
+		}
+	}
+	if value.Custom != nil {
+		if err := validateCustomPolicy(value.Custom); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "custom:"))
+		}
+	}
+	return errs
+}
+
+// ValidateExternalService checks external service declarations
+func ValidateExternalService(msg proto.Message) error {
+	value, ok := msg.(*proxyconfig.ExternalService)
+	if !ok {
+		return fmt.Errorf("Cannot cast to external service")
+	}
+
+	var errs error
+	svc := &Service{Hostname: value.Hostname}
+	ports := make(map[int32]bool)
+	for _, port := range value.Ports {
+		protocol := Protocol(port.Protocol)
+		switch protocol {
+		case ProtocolHTTP, ProtocolHTTP2, ProtocolGRPC, ProtocolHTTPS, ProtocolTCP:
+		default:
+			errs = multierror.Append(errs, fmt.Errorf("Unsupported protocol %q for port %d", port.Protocol, port.Port))
+		}
+		if port.Port <= 0 || port.Port > 65535 {
+			errs = multierror.Append(errs, fmt.Errorf("Invalid port number %d", port.Port))
+		} else if ports[port.Port] {
+			errs = multierror.Append(errs, fmt.Errorf("Duplicate port number %d", port.Port))
+		}
+		ports[port.Port] = true
+		svc.Ports = append(svc.Ports, &Port{Name: port.Name, Port: int(port.Port), Protocol: protocol})
+	}
+	if err := svc.Validate(); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	if _, ok := proxyconfig.ExternalService_Resolution_name[int32(value.Resolution)]; !ok {
+		errs = multierror.Append(errs, fmt.Errorf("Unknown resolution %d", value.Resolution))
+	}
+	return errs
+}
+
+// RegisterCustomPolicy declares a validation function for the custom policy
+// payloads with the type URL. Destination policies with custom payloads of
+// undeclared types are rejected.
+func RegisterCustomPolicy(typeURL string, validate func(*any.Any) error) {
+	customPoliciesMutex.Lock()
+	defer customPoliciesMutex.Unlock()
+	customPolicies[typeURL] = validate
+}
+
+func validateCustomPolicy(payload *any.Any) error {
+	if payload == nil {
+		return fmt.Errorf("Missing custom policy")
+	}
+	customPoliciesMutex.RLock()
+	validate, ok := customPolicies[payload.TypeUrl]
+	customPoliciesMutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("Unknown custom policy type %q", payload.TypeUrl)
+	}
+	return validate(payload)
+}
+
+func validatePercent(percent float32) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("Percent %v must be in range [0, 100]", percent)
+	}
 	return nil
 }
+
+func validateCircuitBreaker(cb *proxyconfig.CircuitBreaker) error {
+	switch policy := cb.CbPolicy.(type) {
+	case *proxyconfig.CircuitBreaker_Custom:
+		return validateCustomPolicy(policy.Custom)
+	case *proxyconfig.CircuitBreaker_SimpleCb:
+		simple := policy.SimpleCb
+		if simple == nil {
+			return fmt.Errorf("Missing simple circuit breaker policy")
+		}
+		var errs error
+		thresholds := []struct {
+			name  string
+			value int32
+		}{
+			{"success_threshold", simple.SuccessThreshold},
+			{"failure_threshold", simple.FailureThreshold},
+			{"max_connections", simple.MaxConnections},
+			{"http_max_pending_requests", simple.HttpMaxPendingRequests},
+			{"http_max_requests", simple.HttpMaxRequests},
+			{"http_consecutive_errors", simple.HttpConsecutiveErrors},
+			{"http_detection_interval", simple.HttpDetectionInterval},
+			{"http_max_requests_per_connection", simple.HttpMaxRequestsPerConnection},
+		}
+		for _, threshold := range thresholds {
+			if threshold.value < 0 {
+				errs = multierror.Append(errs, fmt.Errorf("Negative %s %d", threshold.name, threshold.value))
+			}
+		}
+		if simple.ResetTimeoutSeconds < 0 {
+			errs = multierror.Append(errs, fmt.Errorf("Negative reset_timeout_seconds %v", simple.ResetTimeoutSeconds))
+		}
+		if simple.SleepWindow != "" {
+			if window, err := time.ParseDuration(simple.SleepWindow); err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("Invalid sleep_window %q: %v", simple.SleepWindow, err))
+			} else if window < 0 {
+				errs = multierror.Append(errs, fmt.Errorf("Negative sleep_window %q", simple.SleepWindow))
+			}
+		}
+		return errs
+	default:
+		return fmt.Errorf("Missing circuit breaker policy")
+	}
+}
+
+// validateHTTPTimeout checks a timeout policy. A simple timeout policy cannot
+// use a custom override header name, only TimeoutOverrideHeader is supported.
+func validateHTTPTimeout(timeout *proxyconfig.HTTPTimeout) error {
+	switch policy := timeout.TimeoutPolicy.(type) {
+	case *proxyconfig.HTTPTimeout_Custom:
+		return validateCustomPolicy(policy.Custom)
+	case *proxyconfig.HTTPTimeout_SimpleTimeout:
+		if policy.SimpleTimeout == nil || policy.SimpleTimeout.TimeoutSeconds <= 0 {
+			return fmt.Errorf("Timeout must be positive")
+		}
+		return validateOverrideHeader(policy.SimpleTimeout.OverrideHeaderName, TimeoutOverrideHeader)
+	default:
+		return fmt.Errorf("Missing timeout policy")
+	}
+}
+
+// validateHTTPRetry checks a retry policy. A simple retry policy cannot use a
+// custom override header name, only RetriesOverrideHeader is supported.
+func validateHTTPRetry(retry *proxyconfig.HTTPRetry) error {
+	switch policy := retry.RetryPolicy.(type) {
+	case *proxyconfig.HTTPRetry_Custom:
+		return validateCustomPolicy(policy.Custom)
+	case *proxyconfig.HTTPRetry_SimpleRetry:
+		if policy.SimpleRetry == nil || policy.SimpleRetry.Attempts <= 0 {
+			return fmt.Errorf("Retry attempts must be positive")
+		}
+		return validateOverrideHeader(policy.SimpleRetry.OverrideHeaderName, RetriesOverrideHeader)
+	default:
+		return fmt.Errorf("Missing retry policy")
+	}
+}
+
+// validateOverrideHeader checks that an override header is either omitted or
+// the header that the proxy supports, since the proxy cannot rename headers
+func validateOverrideHeader(name, supported string) error {
+	if name != "" && !strings.EqualFold(name, supported) {
+		return fmt.Errorf("Unsupported override header %q, must be %q", name, supported)
+	}
+	return nil
+}
+
+func validateHTTPFault(fault *proxyconfig.HTTPFaultInjection) error {
+	var errs error
+	if fault.Delay != nil {
+		switch delay := fault.Delay.HttpDelayType.(type) {
+		case *proxyconfig.HTTPFaultInjection_Delay_FixedDelay:
+			if err := validatePercent(delay.FixedDelay.GetPercent()); err != nil {
+				errs = multierror.Append(errs, multierror.Prefix(err, "delay:"))
+			}
+			if delay.FixedDelay.GetFixedDelaySeconds() <= 0 {
+				errs = multierror.Append(errs, fmt.Errorf("delay: Delay must be positive"))
+			}
+		case *proxyconfig.HTTPFaultInjection_Delay_ExpDelay:
+			if err := validatePercent(delay.ExpDelay.GetPercent()); err != nil {
+				errs = multierror.Append(errs, multierror.Prefix(err, "delay:"))
+			}
+			if delay.ExpDelay.GetMeanDelaySeconds() <= 0 {
+				errs = multierror.Append(errs, fmt.Errorf("delay: Mean delay must be positive"))
+			}
+		default:
+			errs = multierror.Append(errs, fmt.Errorf("delay: Missing delay type"))
+		}
+	}
+	if fault.Abort != nil {
+		if err := validatePercent(fault.Abort.Percent); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "abort:"))
+		}
+		switch abort := fault.Abort.ErrorType.(type) {
+		case *proxyconfig.HTTPFaultInjection_Abort_HttpStatus:
+			if http.StatusText(int(abort.HttpStatus)) == "" {
+				errs = multierror.Append(errs, fmt.Errorf("abort: Invalid HTTP status %d", abort.HttpStatus))
+			}
+		case *proxyconfig.HTTPFaultInjection_Abort_GrpcStatus, *proxyconfig.HTTPFaultInjection_Abort_Http2Error:
+		default:
+			errs = multierror.Append(errs, fmt.Errorf("abort: Missing error type"))
+		}
+	}
+	for name, match := range fault.Headers {
+		if err := validateStringMatch(match); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, fmt.Sprintf("header %q:", name)))
+		}
+	}
+	return errs
+}
+
+func validateL4Fault(fault *proxyconfig.L4FaultInjection) error {
+	var errs error
+	if fault.Throttle != nil {
+		if err := validatePercent(fault.Throttle.Percent); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "throttle:"))
+		}
+		if fault.Throttle.DownstreamLimitBps < 0 || fault.Throttle.UpstreamLimitBps < 0 {
+			errs = multierror.Append(errs, fmt.Errorf("throttle: Bandwidth limits must be non-negative"))
+		}
+	}
+	if fault.Terminate != nil {
+		if err := validatePercent(fault.Terminate.Percent); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "terminate:"))
+		}
+		if fault.Terminate.TerminateAfterSeconds < 0 {
+			errs = multierror.Append(errs, fmt.Errorf("terminate: Negative terminate_after_seconds"))
+		}
+	}
+	return errs
+}
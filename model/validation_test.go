@@ -0,0 +1,356 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/any"
+
+	proxyconfig "istio.io/manager/model/proxy/alphav1/config"
+)
+
+func TestValidateDestination(t *testing.T) {
+	const registered = "type.googleapis.com/test.Registered"
+	RegisterCustomPolicy(registered, func(payload *any.Any) error {
+		if len(payload.Value) == 0 {
+			return fmt.Errorf("Empty payload")
+		}
+		return nil
+	})
+
+	cases := []struct {
+		name   string
+		policy *proxyconfig.Destination
+		valid  bool
+	}{
+		{
+			name: "valid",
+			policy: &proxyconfig.Destination{
+				Destination: "hello.default.svc.cluster.local",
+				Tags:        map[string]string{"version": "v1"},
+				CircuitBreaker: &proxyconfig.CircuitBreaker{
+					CbPolicy: &proxyconfig.CircuitBreaker_SimpleCb{
+						SimpleCb: &proxyconfig.CircuitBreaker_SimpleCircuitBreakerPolicy{
+							MaxConnections: 100,
+							SleepWindow:    "15s",
+						},
+					},
+				},
+				HttpTimeout: &proxyconfig.HTTPTimeout{
+					TimeoutPolicy: &proxyconfig.HTTPTimeout_SimpleTimeout{
+						SimpleTimeout: &proxyconfig.HTTPTimeout_SimpleTimeoutPolicy{TimeoutSeconds: 1.5},
+					},
+				},
+				HttpRetry: &proxyconfig.HTTPRetry{
+					RetryPolicy: &proxyconfig.HTTPRetry_SimpleRetry{
+						SimpleRetry: &proxyconfig.HTTPRetry_SimpleRetryPolicy{Attempts: 3},
+					},
+				},
+				HttpFault: &proxyconfig.HTTPFaultInjection{
+					Delay: &proxyconfig.HTTPFaultInjection_Delay{
+						HttpDelayType: &proxyconfig.HTTPFaultInjection_Delay_FixedDelay{
+							FixedDelay: &proxyconfig.HTTPFaultInjection_FixedDelay{Percent: 100, FixedDelaySeconds: 5},
+						},
+					},
+					Abort: &proxyconfig.HTTPFaultInjection_Abort{
+						Percent:   10,
+						ErrorType: &proxyconfig.HTTPFaultInjection_Abort_HttpStatus{HttpStatus: 503},
+					},
+				},
+				Custom: &any.Any{TypeUrl: registered, Value: []byte{1}},
+			},
+			valid: true,
+		},
+		{
+			name:   "missing destination",
+			policy: &proxyconfig.Destination{},
+		},
+		{
+			name: "invalid tags",
+			policy: &proxyconfig.Destination{
+				Destination: "hello",
+				Tags:        map[string]string{"version": "v 1"},
+			},
+		},
+		{
+			name: "fault percent out of range",
+			policy: &proxyconfig.Destination{
+				Destination: "hello",
+				HttpFault: &proxyconfig.HTTPFaultInjection{
+					Abort: &proxyconfig.HTTPFaultInjection_Abort{
+						Percent:   110,
+						ErrorType: &proxyconfig.HTTPFaultInjection_Abort_HttpStatus{HttpStatus: 503},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid abort status",
+			policy: &proxyconfig.Destination{
+				Destination: "hello",
+				HttpFault: &proxyconfig.HTTPFaultInjection{
+					Abort: &proxyconfig.HTTPFaultInjection_Abort{
+						Percent:   10,
+						ErrorType: &proxyconfig.HTTPFaultInjection_Abort_HttpStatus{HttpStatus: 999},
+					},
+				},
+			},
+		},
+		{
+			name: "zero timeout",
+			policy: &proxyconfig.Destination{
+				Destination: "hello",
+				HttpTimeout: &proxyconfig.HTTPTimeout{
+					TimeoutPolicy: &proxyconfig.HTTPTimeout_SimpleTimeout{
+						SimpleTimeout: &proxyconfig.HTTPTimeout_SimpleTimeoutPolicy{},
+					},
+				},
+			},
+		},
+		{
+			name: "zone aware routing",
+			policy: &proxyconfig.Destination{
+				Destination:      "hello",
+				ZoneAwareRouting: true,
+			},
+			valid: true,
+		},
+		{
+			name: "zone aware routing for tags",
+			policy: &proxyconfig.Destination{
+				Destination:      "hello",
+				Tags:             map[string]string{"version": "v1"},
+				ZoneAwareRouting: true,
+			},
+		},
+		{
+			name: "supported override headers",
+			policy: &proxyconfig.Destination{
+				Destination: "hello",
+				HttpTimeout: &proxyconfig.HTTPTimeout{
+					TimeoutPolicy: &proxyconfig.HTTPTimeout_SimpleTimeout{
+						SimpleTimeout: &proxyconfig.HTTPTimeout_SimpleTimeoutPolicy{
+							TimeoutSeconds:     1,
+							OverrideHeaderName: "X-Envoy-Upstream-Rq-Timeout-Ms",
+						},
+					},
+				},
+				HttpRetry: &proxyconfig.HTTPRetry{
+					RetryPolicy: &proxyconfig.HTTPRetry_SimpleRetry{
+						SimpleRetry: &proxyconfig.HTTPRetry_SimpleRetryPolicy{
+							Attempts:           3,
+							OverrideHeaderName: RetriesOverrideHeader,
+						},
+					},
+				},
+			},
+			valid: true,
+		},
+		{
+			name: "unsupported timeout override header",
+			policy: &proxyconfig.Destination{
+				Destination: "hello",
+				HttpTimeout: &proxyconfig.HTTPTimeout{
+					TimeoutPolicy: &proxyconfig.HTTPTimeout_SimpleTimeout{
+						SimpleTimeout: &proxyconfig.HTTPTimeout_SimpleTimeoutPolicy{
+							TimeoutSeconds:     1,
+							OverrideHeaderName: "x-timeout",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "unsupported retry override header",
+			policy: &proxyconfig.Destination{
+				Destination: "hello",
+				HttpRetry: &proxyconfig.HTTPRetry{
+					RetryPolicy: &proxyconfig.HTTPRetry_SimpleRetry{
+						SimpleRetry: &proxyconfig.HTTPRetry_SimpleRetryPolicy{
+							Attempts:           3,
+							OverrideHeaderName: "x-retries",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "negative retry attempts",
+			policy: &proxyconfig.Destination{
+				Destination: "hello",
+				HttpRetry: &proxyconfig.HTTPRetry{
+					RetryPolicy: &proxyconfig.HTTPRetry_SimpleRetry{
+						SimpleRetry: &proxyconfig.HTTPRetry_SimpleRetryPolicy{Attempts: -1},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid sleep window",
+			policy: &proxyconfig.Destination{
+				Destination: "hello",
+				CircuitBreaker: &proxyconfig.CircuitBreaker{
+					CbPolicy: &proxyconfig.CircuitBreaker_SimpleCb{
+						SimpleCb: &proxyconfig.CircuitBreaker_SimpleCircuitBreakerPolicy{SleepWindow: "15"},
+					},
+				},
+			},
+		},
+		{
+			name: "negative circuit breaker threshold",
+			policy: &proxyconfig.Destination{
+				Destination: "hello",
+				CircuitBreaker: &proxyconfig.CircuitBreaker{
+					CbPolicy: &proxyconfig.CircuitBreaker_SimpleCb{
+						SimpleCb: &proxyconfig.CircuitBreaker_SimpleCircuitBreakerPolicy{FailureThreshold: -1},
+					},
+				},
+			},
+		},
+		{
+			name: "unknown custom policy",
+			policy: &proxyconfig.Destination{
+				Destination: "hello",
+				Custom:      &any.Any{TypeUrl: "type.googleapis.com/test.Unknown"},
+			},
+		},
+		{
+			name: "invalid custom payload",
+			policy: &proxyconfig.Destination{
+				Destination: "hello",
+				HttpRetry: &proxyconfig.HTTPRetry{
+					RetryPolicy: &proxyconfig.HTTPRetry_Custom{Custom: &any.Any{TypeUrl: registered}},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		err := ValidateDestination(c.policy)
+		if c.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		} else if !c.valid && err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+}
+
+func TestValidateExternalService(t *testing.T) {
+	httpPort := &proxyconfig.ExternalService_Port{Port: 80, Protocol: string(ProtocolHTTP)}
+	cases := []struct {
+		name  string
+		svc   *proxyconfig.ExternalService
+		valid bool
+	}{
+		{
+			name: "valid",
+			svc: &proxyconfig.ExternalService{
+				Hostname: "api.example.com",
+				Ports: []*proxyconfig.ExternalService_Port{
+					{Name: "http", Port: 80, Protocol: string(ProtocolHTTP)},
+					{Name: "https", Port: 443, Protocol: string(ProtocolHTTPS)},
+				},
+				Resolution:     proxyconfig.ExternalService_LOGICAL_DNS,
+				TlsOrigination: true,
+			},
+			valid: true,
+		},
+		{
+			name: "invalid hostname",
+			svc: &proxyconfig.ExternalService{
+				Hostname: "api_example.com",
+				Ports:    []*proxyconfig.ExternalService_Port{httpPort},
+			},
+		},
+		{
+			name: "no ports",
+			svc:  &proxyconfig.ExternalService{Hostname: "api.example.com"},
+		},
+		{
+			name: "unsupported protocol",
+			svc: &proxyconfig.ExternalService{
+				Hostname: "api.example.com",
+				Ports:    []*proxyconfig.ExternalService_Port{{Port: 53, Protocol: string(ProtocolUDP)}},
+			},
+		},
+		{
+			name: "duplicate port",
+			svc: &proxyconfig.ExternalService{
+				Hostname: "api.example.com",
+				Ports: []*proxyconfig.ExternalService_Port{
+					{Name: "a", Port: 80, Protocol: string(ProtocolHTTP)},
+					{Name: "b", Port: 80, Protocol: string(ProtocolHTTP)},
+				},
+			},
+		},
+		{
+			name: "unknown resolution",
+			svc: &proxyconfig.ExternalService{
+				Hostname:   "api.example.com",
+				Ports:      []*proxyconfig.ExternalService_Port{httpPort},
+				Resolution: 5,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		err := ValidateExternalService(c.svc)
+		if c.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		} else if !c.valid && err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+}
+
+func TestValidateRouteRuleSubnets(t *testing.T) {
+	rule := func(tcp *proxyconfig.L4MatchAttributes) *proxyconfig.RouteRule {
+		return &proxyconfig.RouteRule{
+			Destination: "db.default.svc.cluster.local",
+			Match:       &proxyconfig.MatchCondition{Tcp: tcp},
+		}
+	}
+	valid := &proxyconfig.L4MatchAttributes{
+		SourceSubnet:      []string{"10.0.0.0/8", "10.1.2.3"},
+		DestinationSubnet: []string{"2001:db8::/32", "2001:db8::1"},
+	}
+	if err := ValidateRouteRule(rule(valid)); err != nil {
+		t.Errorf("ValidateRouteRule(%v) => unexpected error %v", valid, err)
+	}
+	for _, invalid := range []*proxyconfig.L4MatchAttributes{
+		{SourceSubnet: []string{"10.0.0.0/33"}},
+		{SourceSubnet: []string{"10.0.0"}},
+		{DestinationSubnet: []string{"db.default.svc.cluster.local"}},
+	} {
+		if err := ValidateRouteRule(rule(invalid)); err == nil {
+			t.Errorf("ValidateRouteRule(%v) => expected an error", invalid)
+		}
+	}
+}
+
+func TestValidateEndpointWeight(t *testing.T) {
+	for _, weight := range []int{1, 50, 100} {
+		if err := ValidateEndpointWeight(weight); err != nil {
+			t.Errorf("ValidateEndpointWeight(%d) => unexpected error %v", weight, err)
+		}
+	}
+	for _, weight := range []int{-1, 0, 101} {
+		if err := ValidateEndpointWeight(weight); err == nil {
+			t.Errorf("ValidateEndpointWeight(%d) => expected an error", weight)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// SchemaVersion declares an older version of the schema of a configuration kind
+type SchemaVersion struct {
+	// Version of the schema
+	Version string
+	// MessageName refers to the protobuf message type name of the version
+	MessageName string
+	// Upgrade converts a message of the version to the next newer version
+	Upgrade func(proto.Message) (proto.Message, error)
+}
+
+// Versions lists the schema versions from the oldest to the current version
+func (ps *ProtoSchema) Versions() []string {
+	out := make([]string, 0, len(ps.Previous)+1)
+	for _, previous := range ps.Previous {
+		out = append(out, previous.Version)
+	}
+	return append(out, ps.Version)
+}
+
+// OldestVersion returns the version of objects stored without a version,
+// which were written before the schema had versions
+func (ps *ProtoSchema) OldestVersion() string {
+	if len(ps.Previous) > 0 {
+		return ps.Previous[0].Version
+	}
+	return ps.Version
+}
+
+// FromJSONVersion converts a canonical JSON of a schema version to a proto
+// message of the current version. An empty version refers to the oldest version.
+func (ps *ProtoSchema) FromJSONVersion(version, js string) (proto.Message, error) {
+	if version == "" {
+		version = ps.OldestVersion()
+	}
+	if version == ps.Version {
+		return ps.FromJSON(js)
+	}
+	for i, previous := range ps.Previous {
+		if previous.Version != version {
+			continue
+		}
+		pbt := proto.MessageType(previous.MessageName)
+		if pbt == nil {
+			return nil, fmt.Errorf("Cannot find proto message type: %q", previous.MessageName)
+		}
+		pb := reflect.New(pbt.Elem()).Interface().(proto.Message)
+		if err := jsonpb.UnmarshalString(js, pb); err != nil {
+			return nil, err
+		}
+		return ps.upgrade(i, pb)
+	}
+	return nil, fmt.Errorf("Unsupported schema version %q of %q", version, ps.MessageName)
+}
+
+// FromJSONMapVersion converts a generic map of a schema version to a proto
+// message of the current version
+func (ps *ProtoSchema) FromJSONMapVersion(version string, data map[string]interface{}) (proto.Message, error) {
+	str, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return ps.FromJSONVersion(version, string(str))
+}
+
+// upgrade converts a message of the previous version i to the current version
+func (ps *ProtoSchema) upgrade(i int, msg proto.Message) (proto.Message, error) {
+	var err error
+	for ; i < len(ps.Previous); i++ {
+		previous := ps.Previous[i]
+		if msg, err = previous.Upgrade(msg); err != nil {
+			return nil, fmt.Errorf("Cannot upgrade from schema version %q: %v", previous.Version, err)
+		}
+	}
+	if proto.MessageName(msg) != ps.MessageName {
+		return nil, fmt.Errorf("Upgrade produced message type %q instead of %q", proto.MessageName(msg), ps.MessageName)
+	}
+	return msg, nil
+}
+
+// Migration describes an object rewritten in the current schema version
+type Migration struct {
+	Key
+	// From is the stored schema version
+	From string
+	// To is the current schema version
+	To string
+}
+
+// Migrate rewrites the objects of all kinds in a namespace (or all namespaces
+// if namespace is "") that are stored in an older schema version. The objects
+// are updated at their listed revisions, so that concurrent changes are not
+// overwritten. With dry run, the objects are reported but not changed.
+func Migrate(registry ConfigRegistry, mapping KindMap, namespace string, dryRun bool) ([]Migration, error) {
+	kinds := make([]string, 0, len(mapping))
+	for kind := range mapping {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var errs error
+	out := make([]Migration, 0)
+	for _, kind := range kinds {
+		configs, err := registry.List(kind, namespace)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("Cannot list %s: %v", kind, err))
+			continue
+		}
+		schema := mapping[kind]
+		for _, config := range configs {
+			if config.Version == "" || config.Version == schema.Version {
+				continue
+			}
+			if !dryRun {
+				if _, err = registry.Put(config.Key, config.Content, config.Revision); err != nil {
+					errs = multierror.Append(errs, fmt.Errorf("Cannot migrate %v: %v", config.Key, err))
+					continue
+				}
+				glog.V(2).Infof("Migrated %v from schema version %s", config.Key, config.Version)
+			}
+			out = append(out, Migration{Key: config.Key, From: config.Version, To: schema.Version})
+		}
+	}
+	return out, errs
+}
@@ -0,0 +1,130 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+
+	proxyconfig "istio.io/manager/model/proxy/alphav1/config"
+)
+
+// versionedSchema declares route rules that used to be plain destination strings
+var versionedSchema = ProtoSchema{
+	MessageName: RouteRuleProto,
+	Validate:    ValidateRouteRule,
+	Version:     "v2",
+	Previous: []SchemaVersion{{
+		Version:     "v1",
+		MessageName: "google.protobuf.StringValue",
+		Upgrade: func(msg proto.Message) (proto.Message, error) {
+			value := msg.(*wrappers.StringValue).Value
+			if value == "" {
+				return nil, fmt.Errorf("Empty destination")
+			}
+			return &proxyconfig.RouteRule{Destination: value}, nil
+		},
+	}},
+}
+
+func TestFromJSONVersion(t *testing.T) {
+	if err := (KindMap{RouteRule: versionedSchema}).Validate(); err != nil {
+		t.Error(err)
+	}
+	if got := versionedSchema.Versions(); !reflect.DeepEqual(got, []string{"v1", "v2"}) {
+		t.Errorf("Versions() => %v", got)
+	}
+
+	want := &proxyconfig.RouteRule{Destination: hello}
+	cases := []struct {
+		version string
+		js      string
+	}{
+		{"v2", `{"destination":"` + hello + `"}`},
+		{"v1", `"` + hello + `"`},
+		{"", `"` + hello + `"`},
+	}
+	for _, c := range cases {
+		out, err := versionedSchema.FromJSONVersion(c.version, c.js)
+		if err != nil {
+			t.Errorf("FromJSONVersion(%q) => unexpected error %v", c.version, err)
+		} else if !proto.Equal(out, want) {
+			t.Errorf("FromJSONVersion(%q) => got %v, want %v", c.version, out, want)
+		}
+	}
+
+	if _, err := versionedSchema.FromJSONVersion("v1", `""`); err == nil {
+		t.Error("expected an upgrade error")
+	}
+	if _, err := versionedSchema.FromJSONVersion("v3", `{}`); err == nil {
+		t.Error("expected an error for an unsupported version")
+	}
+}
+
+// versionedRegistry lists objects in a fixed version and records updates
+type versionedRegistry struct {
+	fakeConfig
+	puts []Key
+}
+
+func (r *versionedRegistry) Put(key Key, v proto.Message, revision string) (string, error) {
+	if revision != "1" {
+		return "", &ConflictError{Key: key, Revision: revision}
+	}
+	r.puts = append(r.puts, key)
+	return "2", nil
+}
+
+func TestMigrate(t *testing.T) {
+	stale := Config{
+		Key:      Key{Kind: RouteRule, Name: "stale", Namespace: "default"},
+		Revision: "1",
+		Version:  "v1",
+		Content:  &proxyconfig.RouteRule{Destination: hello},
+	}
+	current := Config{
+		Key:      Key{Kind: RouteRule, Name: "current", Namespace: "default"},
+		Revision: "1",
+		Content:  &proxyconfig.RouteRule{Destination: world},
+	}
+	registry := &versionedRegistry{fakeConfig: fakeConfig{configs: []Config{stale, current}}}
+	mapping := KindMap{RouteRule: versionedSchema}
+
+	migrations, err := Migrate(registry, mapping, "", true)
+	if err != nil {
+		t.Error(err)
+	}
+	want := []Migration{{Key: stale.Key, From: "v1", To: "v2"}}
+	if !reflect.DeepEqual(migrations, want) || len(registry.puts) != 0 {
+		t.Errorf("dry run => got %v and updates %v, want %v", migrations, registry.puts, want)
+	}
+
+	migrations, err = Migrate(registry, mapping, "", false)
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(migrations, want) || !reflect.DeepEqual(registry.puts, []Key{stale.Key}) {
+		t.Errorf("migrate => got %v and updates %v, want %v", migrations, registry.puts, want)
+	}
+
+	registry.configs[0].Revision = "0"
+	if _, err = Migrate(registry, mapping, "", false); err == nil {
+		t.Error("expected a conflict error")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package aggregate composes the service registries of several platforms
+// into a single service discovery and controller.
+package aggregate
+
+import (
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	multierror "github.com/hashicorp/go-multierror"
+
+	"istio.io/manager/model"
+)
+
+// Registry is a named platform service registry together with its controller
+type Registry struct {
+	Name string
+	model.ServiceDiscovery
+	model.Controller
+}
+
+// Controller merges the services and instances of several registries.
+//
+// The registries are ordered by priority: when several registries declare
+// the same hostname, the service declaration of the first one wins.
+// Instances are collected from all registries.
+type Controller struct {
+	registries []Registry
+}
+
+// NewController creates an aggregate controller for the registries in the
+// order of priority
+func NewController(registries ...Registry) *Controller {
+	return &Controller{registries: registries}
+}
+
+// Services implements a service catalog operation
+func (c *Controller) Services() []*model.Service {
+	out := make([]*model.Service, 0)
+	origin := make(map[string]string)
+	for _, r := range c.registries {
+		for _, svc := range r.Services() {
+			if other, exists := origin[svc.Hostname]; exists {
+				glog.V(2).Infof("Service %s from registry %s is shadowed by registry %s", svc.Hostname, r.Name, other)
+				continue
+			}
+			origin[svc.Hostname] = r.Name
+			out = append(out, svc)
+		}
+	}
+	return out
+}
+
+// GetService implements a service catalog operation
+func (c *Controller) GetService(hostname string) (*model.Service, bool) {
+	for _, r := range c.registries {
+		if svc, exists := r.GetService(hostname); exists {
+			return svc, true
+		}
+	}
+	return nil, false
+}
+
+// Instances implements a service catalog operation
+func (c *Controller) Instances(hostname string, ports []string, tagsList model.TagsList,
+	health model.HealthFilter) []*model.ServiceInstance {
+	out := make([]*model.ServiceInstance, 0)
+	for _, r := range c.registries {
+		out = append(out, r.Instances(hostname, ports, tagsList, health)...)
+	}
+	return out
+}
+
+// HostInstances implements a service catalog operation
+func (c *Controller) HostInstances(addrs map[string]bool) []*model.ServiceInstance {
+	out := make([]*model.ServiceInstance, 0)
+	for _, r := range c.registries {
+		out = append(out, r.HostInstances(addrs)...)
+	}
+	return out
+}
+
+// AppendConfigHandler implements a controller operation
+func (c *Controller) AppendConfigHandler(kind string, f func(model.Key, proto.Message, model.Event)) error {
+	var errs error
+	for _, r := range c.registries {
+		if err := r.AppendConfigHandler(kind, f); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, r.Name+":"))
+		}
+	}
+	return errs
+}
+
+// AppendServiceHandler implements a controller operation
+func (c *Controller) AppendServiceHandler(f func(*model.Service, model.Event)) error {
+	var errs error
+	for _, r := range c.registries {
+		if err := r.AppendServiceHandler(f); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, r.Name+":"))
+		}
+	}
+	return errs
+}
+
+// AppendInstanceHandler implements a controller operation
+func (c *Controller) AppendInstanceHandler(f func(*model.ServiceInstance, model.Event)) error {
+	var errs error
+	for _, r := range c.registries {
+		if err := r.AppendInstanceHandler(f); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, r.Name+":"))
+		}
+	}
+	return errs
+}
+
+// Run starts all registry controllers and waits until a signal is received
+func (c *Controller) Run(stop chan struct{}) {
+	for _, r := range c.registries {
+		go r.Run(stop)
+	}
+	<-stop
+	glog.V(2).Info("Registry aggregator terminated")
+}
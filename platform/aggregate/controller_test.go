@@ -0,0 +1,116 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregate
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"istio.io/manager/model"
+	"istio.io/manager/platform/memory"
+)
+
+var (
+	hostname = "db.example.com"
+	httpPort = &model.Port{Name: "http", Port: 80, Protocol: model.ProtocolHTTP}
+)
+
+func makeRegistry(t *testing.T, name, address string, ips ...string) (Registry, *memory.Controller) {
+	ctl := memory.NewController(model.IstioConfig)
+	svc := &model.Service{Hostname: hostname, Address: address, Ports: model.PortList{httpPort}}
+	if err := ctl.AddService(svc); err != nil {
+		t.Fatal(err)
+	}
+	for _, ip := range ips {
+		if err := ctl.AddInstance(&model.ServiceInstance{
+			Endpoint: model.NetworkEndpoint{Address: ip, Port: 8080, ServicePort: httpPort},
+			Service:  svc,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return Registry{Name: name, ServiceDiscovery: ctl, Controller: ctl}, ctl
+}
+
+func TestController(t *testing.T) {
+	kube, _ := makeRegistry(t, "kubernetes", "10.1.0.1", "10.0.0.1")
+	vms, store := makeRegistry(t, "file", "10.2.0.1", "10.0.1.1", "10.0.1.2")
+
+	ctl := NewController(kube, vms)
+	var _ model.Controller = ctl
+	var _ model.ServiceDiscovery = ctl
+
+	if svcs := ctl.Services(); len(svcs) != 1 || svcs[0].Address != "10.1.0.1" {
+		t.Errorf("Services() => got %v, want the kubernetes declaration", svcs)
+	}
+	if svc, exists := ctl.GetService(hostname); !exists || svc.Address != "10.1.0.1" {
+		t.Errorf("GetService() => got %v, want the kubernetes declaration", svc)
+	}
+	if svc, _ := NewController(vms, kube).GetService(hostname); svc.Address != "10.2.0.1" {
+		t.Errorf("GetService() => got %v, want the file declaration", svc)
+	}
+	if _, exists := ctl.GetService("missing.example.com"); exists {
+		t.Error("GetService(missing) => got a service")
+	}
+
+	if out := ctl.Instances(hostname, []string{"http"}, nil, model.AnyHealth); len(out) != 3 {
+		t.Errorf("Instances() => got %d, want 3", len(out))
+	}
+	if out := ctl.HostInstances(map[string]bool{"10.0.0.1": true, "10.0.1.2": true}); len(out) != 2 {
+		t.Errorf("HostInstances() => got %d, want 2", len(out))
+	}
+
+	var mu sync.Mutex
+	count := 0
+	if err := ctl.AppendInstanceHandler(func(*model.ServiceInstance, model.Event) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctl.AppendConfigHandler("missing-kind", nil); err == nil {
+		t.Error("AppendConfigHandler(missing-kind) => got no error")
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go ctl.Run(stop)
+
+	if err := store.DeleteService(hostname); err != nil {
+		t.Fatal(err)
+	}
+	eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return count == 2
+	}, t)
+	if out := ctl.Instances(hostname, []string{"http"}, nil, model.AnyHealth); len(out) != 1 {
+		t.Errorf("Instances() => got %d, want 1", len(out))
+	}
+}
+
+func eventually(f func() bool, t *testing.T) {
+	interval := 8 * time.Millisecond
+	for i := 0; i < 10; i++ {
+		if f() {
+			return
+		}
+		time.Sleep(interval)
+		interval = 2 * interval
+	}
+	t.Fatal("Failed to satisfy function")
+}
@@ -0,0 +1,148 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package external declares the services outside of the mesh from the
+// external service configuration objects, so that they appear in the service
+// discovery next to the services of the platform registries.
+package external
+
+import (
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+
+	"istio.io/manager/model"
+	proxyconfig "istio.io/manager/model/proxy/alphav1/config"
+)
+
+// Controller is a service registry of the external services declared in a
+// configuration registry. External services have no instances: the proxy
+// resolves their hostnames with DNS.
+//
+// The controller does not watch the configuration on its own. Service events
+// are derived from the external service configuration events of the
+// configuration controller, which must be run separately.
+type Controller struct {
+	config model.ConfigRegistry
+	ctl    model.Controller
+}
+
+// NewController creates a registry for the external services in the
+// configuration registry, with service events from the controller
+func NewController(config model.ConfigRegistry, ctl model.Controller) *Controller {
+	return &Controller{config: config, ctl: ctl}
+}
+
+// convertService converts an external service declaration to a service
+func convertService(svc *proxyconfig.ExternalService) *model.Service {
+	ports := make(model.PortList, 0, len(svc.Ports))
+	for _, port := range svc.Ports {
+		ports = append(ports, &model.Port{
+			Name:     port.Name,
+			Port:     int(port.Port),
+			Protocol: model.Protocol(port.Protocol),
+		})
+	}
+	return &model.Service{
+		Hostname: svc.Hostname,
+		Ports:    ports,
+		External: &model.External{
+			LogicalDNS:     svc.Resolution == proxyconfig.ExternalService_LOGICAL_DNS,
+			TLSOrigination: svc.TlsOrigination,
+		},
+	}
+}
+
+// services returns the external services by hostname. If several objects
+// declare the same hostname, the first one by namespace and name wins.
+func (c *Controller) services() map[string]*model.Service {
+	configs, err := c.config.List(model.ExternalService, "")
+	if err != nil {
+		glog.V(2).Infof("External services => %v", err)
+	}
+	out := make(map[string]*model.Service)
+	keys := make(map[string]model.Key)
+	for _, config := range configs {
+		svc, ok := config.Content.(*proxyconfig.ExternalService)
+		if !ok {
+			continue
+		}
+		if other, exists := keys[svc.Hostname]; exists {
+			if other.Namespace < config.Namespace ||
+				(other.Namespace == config.Namespace && other.Name < config.Name) {
+				glog.V(2).Infof("External service %v is shadowed by %v", config.Key, other)
+				continue
+			}
+			glog.V(2).Infof("External service %v is shadowed by %v", other, config.Key)
+		}
+		keys[svc.Hostname] = config.Key
+		out[svc.Hostname] = convertService(svc)
+	}
+	return out
+}
+
+// Services implements a service catalog operation
+func (c *Controller) Services() []*model.Service {
+	services := c.services()
+	out := make([]*model.Service, 0, len(services))
+	for _, svc := range services {
+		out = append(out, svc)
+	}
+	return out
+}
+
+// GetService implements a service catalog operation
+func (c *Controller) GetService(hostname string) (*model.Service, bool) {
+	svc, exists := c.services()[hostname]
+	return svc, exists
+}
+
+// Instances implements a service catalog operation.
+// External services have no instances.
+func (c *Controller) Instances(hostname string, ports []string, tagsList model.TagsList,
+	health model.HealthFilter) []*model.ServiceInstance {
+	return make([]*model.ServiceInstance, 0)
+}
+
+// HostInstances implements a service catalog operation.
+// External services have no instances.
+func (c *Controller) HostInstances(addrs map[string]bool) []*model.ServiceInstance {
+	return make([]*model.ServiceInstance, 0)
+}
+
+// AppendConfigHandler implements a controller operation.
+// The configuration handlers are appended to the configuration controller directly.
+func (c *Controller) AppendConfigHandler(kind string, f func(model.Key, proto.Message, model.Event)) error {
+	return nil
+}
+
+// AppendServiceHandler implements a controller operation
+func (c *Controller) AppendServiceHandler(f func(*model.Service, model.Event)) error {
+	return c.ctl.AppendConfigHandler(model.ExternalService, func(key model.Key, msg proto.Message, ev model.Event) {
+		if svc, ok := msg.(*proxyconfig.ExternalService); ok {
+			f(convertService(svc), ev)
+		}
+	})
+}
+
+// AppendInstanceHandler implements a controller operation.
+// External services have no instances, and the handler is never called.
+func (c *Controller) AppendInstanceHandler(f func(*model.ServiceInstance, model.Event)) error {
+	return nil
+}
+
+// Run waits until a signal is received
+func (c *Controller) Run(stop chan struct{}) {
+	<-stop
+	glog.V(2).Info("External service registry terminated")
+}
@@ -0,0 +1,111 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"istio.io/manager/model"
+	proxyconfig "istio.io/manager/model/proxy/alphav1/config"
+	"istio.io/manager/platform/memory"
+)
+
+const hostname = "api.example.com"
+
+func externalService(port int32) *proxyconfig.ExternalService {
+	return &proxyconfig.ExternalService{
+		Hostname:       hostname,
+		Ports:          []*proxyconfig.ExternalService_Port{{Name: "http", Port: port, Protocol: "HTTP"}},
+		Resolution:     proxyconfig.ExternalService_LOGICAL_DNS,
+		TlsOrigination: true,
+	}
+}
+
+func TestController(t *testing.T) {
+	store := memory.NewController(model.IstioConfig)
+	ctl := NewController(store, store)
+	var _ model.Controller = ctl
+	var _ model.ServiceDiscovery = ctl
+
+	if _, err := store.Post(model.Key{Kind: model.ExternalService, Name: "api", Namespace: "default"},
+		externalService(80)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Post(model.Key{Kind: model.ExternalService, Name: "api", Namespace: "other"},
+		externalService(8080)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &model.Service{
+		Hostname: hostname,
+		Ports:    model.PortList{{Name: "http", Port: 80, Protocol: model.ProtocolHTTP}},
+		External: &model.External{LogicalDNS: true, TLSOrigination: true},
+	}
+	if svcs := ctl.Services(); len(svcs) != 1 || !reflect.DeepEqual(svcs[0], want) {
+		t.Errorf("Services() => got %v, want %v", svcs, want)
+	}
+	if svc, exists := ctl.GetService(hostname); !exists || !reflect.DeepEqual(svc, want) {
+		t.Errorf("GetService() => got %v, want %v", svc, want)
+	}
+	if _, exists := ctl.GetService("missing.example.com"); exists {
+		t.Error("GetService(missing) => got a service")
+	}
+	if out := ctl.Instances(hostname, []string{"http"}, nil, model.AnyHealth); len(out) != 0 {
+		t.Errorf("Instances() => got %v, want none", out)
+	}
+
+	var mu sync.Mutex
+	events := make([]model.Event, 0)
+	if err := ctl.AppendServiceHandler(func(svc *model.Service, ev model.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if svc.Hostname == hostname && svc.External != nil {
+			events = append(events, ev)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go store.Run(stop)
+
+	if err := store.Delete(model.Key{Kind: model.ExternalService, Name: "api", Namespace: "default"}); err != nil {
+		t.Fatal(err)
+	}
+	eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return reflect.DeepEqual(events, []model.Event{model.EventDelete})
+	}, t)
+	if svc, exists := ctl.GetService(hostname); !exists || svc.Ports[0].Port != 8080 {
+		t.Errorf("GetService() => got %v, want the declaration in the other namespace", svc)
+	}
+}
+
+func eventually(f func() bool, t *testing.T) {
+	interval := 8 * time.Millisecond
+	for i := 0; i < 10; i++ {
+		if f() {
+			return
+		}
+		time.Sleep(interval)
+		interval = 2 * interval
+	}
+	t.Fatal("Failed to satisfy function")
+}
@@ -0,0 +1,145 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	multierror "github.com/hashicorp/go-multierror"
+
+	"istio.io/manager/model"
+)
+
+// document is the YAML representation of a configuration object, e.g.
+//
+//	kind: route-rule
+//	name: reviews-default
+//	namespace: default
+//	version: v1alpha
+//	spec:
+//	  destination: reviews.default.svc.cluster.local
+//
+// The optional version selects the schema version of the spec, which defaults
+// to the current schema version of the kind.
+type document struct {
+	Kind      string          `json:"kind"`
+	Name      string          `json:"name"`
+	Namespace string          `json:"namespace"`
+	Version   string          `json:"version"`
+	Spec      json.RawMessage `json:"spec"`
+}
+
+// ParseConfig reads a multi-document YAML input into configuration objects in
+// the order of declaration. Documents without a namespace are placed in the
+// given namespace. Every document is validated against the kind map.
+// The returned error lists all invalid documents by their position.
+func ParseConfig(mapping model.KindMap, namespace string, data []byte) ([]model.Config, error) {
+	docs, err := splitDocuments(data)
+	if err != nil {
+		return nil, err
+	}
+	var errs error
+	out := make([]model.Config, 0)
+	for i, doc := range docs {
+		config, err := parseDocument(mapping, namespace, doc)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("document %d: %v", i+1, err))
+			continue
+		}
+		out = append(out, *config)
+	}
+	return out, errs
+}
+
+func parseDocument(mapping model.KindMap, namespace string, data []byte) (*model.Config, error) {
+	js, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read YAML input: %v", err)
+	}
+	var doc document
+	if err = json.Unmarshal(js, &doc); err != nil {
+		return nil, fmt.Errorf("Cannot parse document: %v", err)
+	}
+	if doc.Namespace == "" {
+		doc.Namespace = namespace
+	}
+	key := model.Key{Kind: doc.Kind, Name: doc.Name, Namespace: doc.Namespace}
+	schema, ok := mapping[key.Kind]
+	if !ok {
+		return nil, fmt.Errorf("Undeclared kind: %q", key.Kind)
+	}
+	spec := "{}"
+	if len(doc.Spec) > 0 {
+		spec = string(doc.Spec)
+	}
+	version := doc.Version
+	if version == "" {
+		version = schema.Version
+	}
+	msg, err := schema.FromJSONVersion(version, spec)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot parse proto message for %v: %v", key, err)
+	}
+	if err = mapping.ValidateConfig(&key, msg); err != nil {
+		return nil, multierror.Prefix(err, key.String()+":")
+	}
+	return &model.Config{Key: key, Content: msg}, nil
+}
+
+// splitDocuments splits a YAML stream on the "---" separator lines and drops
+// the documents that are empty or contain only comments. The lines may be as
+// long as the whole input.
+func splitDocuments(data []byte) ([][]byte, error) {
+	out := make([][]byte, 0)
+	var current bytes.Buffer
+	flush := func() {
+		if !isBlank(current.String()) {
+			out = append(out, append([]byte(nil), current.Bytes()...))
+		}
+		current.Reset()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimRight(line, " \t") == "---" {
+			flush()
+			continue
+		}
+		current.WriteString(line)
+		current.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Cannot split YAML documents: %v", err)
+	}
+	flush()
+	return out, nil
+}
+
+func isBlank(doc string) bool {
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,227 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package file provides a configuration registry backed by a directory of
+// YAML files for environments without a platform API server.
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	multierror "github.com/hashicorp/go-multierror"
+
+	"istio.io/manager/model"
+	"istio.io/manager/platform/memory"
+)
+
+// Controller loads configuration objects from the YAML files in a directory
+// and re-scans the directory whenever its files change. Changes between two
+// scans are delivered to the config handlers as add, update, and delete events.
+//
+// The registry is read-only: configuration is changed by editing the files.
+type Controller struct {
+	dir     string
+	mapping model.KindMap
+	period  time.Duration
+
+	// store holds the last scanned state and dispatches the events
+	store *memory.Controller
+
+	// mu serializes directory scans
+	mu sync.Mutex
+
+	// files keeps the objects loaded from each file, so that a file that
+	// fails to parse retains its previous objects until it is fixed
+	files map[string][]model.Config
+}
+
+// NewController creates a controller for a directory that is also re-scanned
+// at the given period in case a file change notification is missed
+func NewController(dir string, mapping model.KindMap, period time.Duration) *Controller {
+	return &Controller{
+		dir:     dir,
+		mapping: mapping,
+		period:  period,
+		store:   memory.NewController(mapping),
+		files:   make(map[string][]model.Config),
+	}
+}
+
+// Scan reads all YAML files in the directory and applies the difference with
+// the previously scanned state to the registry. The returned error lists
+// every invalid file with its path.
+func (c *Controller) Scan() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs error
+
+	entries, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string][]model.Config)
+	for _, entry := range entries {
+		path := filepath.Join(c.dir, entry.Name())
+		if entry.IsDir() || !isYAML(path) {
+			continue
+		}
+		configs, err := c.readFile(path)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			if previous, ok := c.files[path]; ok {
+				files[path] = previous
+			}
+			continue
+		}
+		files[path] = configs
+	}
+
+	// merge files in the directory order, reporting duplicate keys
+	next := make(map[model.Key]proto.Message)
+	origin := make(map[model.Key]string)
+	for _, entry := range entries {
+		path := filepath.Join(c.dir, entry.Name())
+		for _, config := range files[path] {
+			if other, exists := origin[config.Key]; exists {
+				errs = multierror.Append(errs,
+					fmt.Errorf("%s: duplicate %v, already declared in %s", path, config.Key, other))
+				continue
+			}
+			origin[config.Key] = path
+			next[config.Key] = config.Content
+		}
+	}
+	c.files = files
+
+	if err := c.apply(next); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	return errs
+}
+
+// apply updates the store to match the desired state, emitting events only for changes
+func (c *Controller) apply(next map[model.Key]proto.Message) error {
+	var errs error
+	for kind := range c.mapping {
+		current, err := c.store.List(kind, "")
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		for _, config := range current {
+			if _, exists := next[config.Key]; !exists {
+				if err := c.store.Delete(config.Key); err != nil {
+					errs = multierror.Append(errs, err)
+				}
+			}
+		}
+	}
+	for key, spec := range next {
+		var err error
+		old, exists, revision := c.store.Get(key)
+		if !exists {
+			_, err = c.store.Post(key, spec)
+		} else if !proto.Equal(old, spec) {
+			_, err = c.store.Put(key, spec, revision)
+		} else {
+			continue
+		}
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// readFile parses and validates all documents in a file
+func (c *Controller) readFile(path string) ([]model.Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	configs, err := ParseConfig(c.mapping, "", data)
+	if err != nil {
+		return nil, multierror.Prefix(err, path+":")
+	}
+	return configs, nil
+}
+
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Run scans the directory on every file change until a signal is received
+func (c *Controller) Run(stop chan struct{}) {
+	go c.store.Run(stop)
+
+	scan := func() {
+		if err := c.Scan(); err != nil {
+			glog.Warningf("Configuration directory %s has errors: %v", c.dir, err)
+		}
+	}
+	scan()
+	watch(c.dir, c.period, scan, stop)
+}
+
+// AppendConfigHandler implements a controller operation
+func (c *Controller) AppendConfigHandler(kind string, f func(model.Key, proto.Message, model.Event)) error {
+	return c.store.AppendConfigHandler(kind, f)
+}
+
+// AppendServiceHandler implements a controller operation.
+// The file registry does not declare services, and the handler is never called.
+func (c *Controller) AppendServiceHandler(f func(*model.Service, model.Event)) error {
+	return c.store.AppendServiceHandler(f)
+}
+
+// AppendInstanceHandler implements a controller operation.
+// The file registry does not declare instances, and the handler is never called.
+func (c *Controller) AppendInstanceHandler(f func(*model.ServiceInstance, model.Event)) error {
+	return c.store.AppendInstanceHandler(f)
+}
+
+// Get implements a registry operation
+func (c *Controller) Get(key model.Key) (proto.Message, bool, string) {
+	return c.store.Get(key)
+}
+
+// List implements a registry operation
+func (c *Controller) List(kind, namespace string) ([]model.Config, error) {
+	return c.store.List(kind, namespace)
+}
+
+// Post implements a registry operation
+func (c *Controller) Post(key model.Key, v proto.Message) (string, error) {
+	return "", fmt.Errorf("unsupported operation: cannot post %v into a file registry", key)
+}
+
+// Put implements a registry operation
+func (c *Controller) Put(key model.Key, v proto.Message, revision string) (string, error) {
+	return "", fmt.Errorf("unsupported operation: cannot put %v into a file registry", key)
+}
+
+// Delete implements a registry operation
+func (c *Controller) Delete(key model.Key) error {
+	return fmt.Errorf("unsupported operation: cannot delete %v from a file registry", key)
+}
@@ -0,0 +1,225 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"istio.io/manager/model"
+	proxyconfig "istio.io/manager/model/proxy/alphav1/config"
+)
+
+const (
+	rules = `
+# default route
+kind: route-rule
+name: default
+namespace: default
+spec:
+  destination: hello.default.svc.cluster.local
+  precedence: 1
+---
+kind: destination
+name: hello
+namespace: default
+spec:
+  destination: hello.default.svc.cluster.local
+  loadBalancing:
+    name: RANDOM
+---
+`
+	updatedRule = `
+kind: route-rule
+name: default
+namespace: default
+spec:
+  destination: hello.default.svc.cluster.local
+  precedence: 2
+`
+	invalidRule = `
+kind: route-rule
+name: missing-destination
+namespace: default
+spec:
+  precedence: 2
+`
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	configs, err := ParseConfig(model.IstioConfig, "", []byte(rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("ParseConfig() => got %d objects, want 2", len(configs))
+	}
+	rule, ok := configs[0].Content.(*proxyconfig.RouteRule)
+	if !ok || configs[0].Key.Kind != model.RouteRule || rule.Precedence != 1 {
+		t.Errorf("ParseConfig() => got %v", configs[0])
+	}
+	policy, ok := configs[1].Content.(*proxyconfig.Destination)
+	if !ok || policy.LoadBalancing.GetName() != proxyconfig.LoadBalancing_RANDOM {
+		t.Errorf("ParseConfig() => got %v", configs[1])
+	}
+
+	if _, err = ParseConfig(model.IstioConfig, "", []byte(invalidRule+"---\nkind: unknown\n")); err == nil {
+		t.Error("ParseConfig(invalid) => got no error")
+	}
+
+	// a line longer than the default scanner buffer does not truncate the input
+	long := "# " + strings.Repeat("x", 128*1024) + "\n"
+	configs, err = ParseConfig(model.IstioConfig, "", []byte(long+rules))
+	if err != nil || len(configs) != 2 {
+		t.Errorf("ParseConfig(long line) => got %d objects, %v, want 2", len(configs), err)
+	}
+
+	configs, err = ParseConfig(model.IstioConfig, "test", []byte("kind: route-rule\nname: x\nspec:\n  destination: a.b\n"))
+	if err != nil || len(configs) != 1 || configs[0].Namespace != "test" {
+		t.Errorf("ParseConfig(default namespace) => got %v, %v", configs, err)
+	}
+}
+
+func TestController(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	ctl := NewController(dir, model.IstioConfig, time.Hour)
+	var _ model.Controller = ctl
+	var _ model.ConfigRegistry = ctl
+
+	var mu sync.Mutex
+	events := make(map[model.Event]int)
+	handler := func(k model.Key, o proto.Message, ev model.Event) {
+		mu.Lock()
+		events[ev]++
+		mu.Unlock()
+	}
+	for _, kind := range []string{model.RouteRule, model.Destination} {
+		if err = ctl.AppendConfigHandler(kind, handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile(t, dir, "rules.yaml", rules)
+	writeFile(t, dir, "ignored.txt", "not a config file")
+
+	// wait for the initial scan to avoid racing with the file updates below
+	stop := make(chan struct{})
+	defer close(stop)
+	go ctl.Run(stop)
+	registry := model.IstioRegistry{ConfigRegistry: ctl}
+	eventually(func() bool { return len(registry.Destinations("")) == 1 }, t)
+
+	if err = ctl.Scan(); err != nil {
+		t.Error(err)
+	}
+	if out := registry.RouteRules(""); len(out) != 1 || out[0].Precedence != 1 {
+		t.Errorf("RouteRules() => got %v", out)
+	}
+
+	// an invalid file is reported by path and the previous objects are retained
+	writeFile(t, dir, "rules.yaml", invalidRule)
+	if err = ctl.Scan(); err == nil || !strings.Contains(err.Error(), "rules.yaml") {
+		t.Errorf("Scan() => got %v, want an error for rules.yaml", err)
+	}
+	if out := registry.RouteRules(""); len(out) != 1 {
+		t.Errorf("RouteRules() => got %v, want the previous rule", out)
+	}
+
+	// an update and a removal
+	writeFile(t, dir, "rules.yaml", updatedRule)
+	if err = ctl.Scan(); err != nil {
+		t.Error(err)
+	}
+	if out := registry.RouteRules(""); len(out) != 1 || out[0].Precedence != 2 {
+		t.Errorf("RouteRules() => got %v", out)
+	}
+	if out := registry.Destinations(""); len(out) != 0 {
+		t.Errorf("Destinations() => got %v, want none", out)
+	}
+
+	// rescanning without changes does not produce events
+	if err = ctl.Scan(); err != nil {
+		t.Error(err)
+	}
+
+	expected := map[model.Event]int{model.EventAdd: 2, model.EventUpdate: 1, model.EventDelete: 1}
+	eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		for ev, n := range expected {
+			if events[ev] != n {
+				return false
+			}
+		}
+		return true
+	}, t)
+
+	if _, err = ctl.Post(model.Key{Kind: model.RouteRule, Name: "x", Namespace: "default"}, nil); err == nil {
+		t.Error("Post() => got no error")
+	}
+}
+
+func TestControllerWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	// the period is too long for the test, so the changes must be picked up from file events
+	ctl := NewController(dir, model.IstioConfig, time.Hour)
+	stop := make(chan struct{})
+	defer close(stop)
+	go ctl.Run(stop)
+
+	registry := model.IstioRegistry{ConfigRegistry: ctl}
+	writeFile(t, dir, "rules.yaml", rules)
+	eventually(func() bool { return len(registry.Destinations("")) == 1 }, t)
+
+	if err = os.Remove(filepath.Join(dir, "rules.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	eventually(func() bool { return len(registry.Destinations("")) == 0 }, t)
+}
+
+func eventually(f func() bool, t *testing.T) {
+	interval := 8 * time.Millisecond
+	for i := 0; i < 10; i++ {
+		if f() {
+			return
+		}
+		time.Sleep(interval)
+		interval = 2 * interval
+	}
+	t.Fatal("Failed to satisfy function")
+}
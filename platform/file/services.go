@@ -0,0 +1,366 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	multierror "github.com/hashicorp/go-multierror"
+
+	"istio.io/manager/model"
+	"istio.io/manager/platform/memory"
+)
+
+// serviceDocument is the YAML representation of a service and its instances, e.g.
+//
+//	hostname: db.example.com
+//	address: 10.1.0.1
+//	ports:
+//	- name: http
+//	  port: 80
+//	  protocol: HTTP
+//	instances:
+//	- address: 10.0.0.1
+//	  ports:
+//	    http: 8080
+//	  tags:
+//	    version: v1
+//
+// Instance ports map service port names to endpoint ports. An instance without
+// ports listens on every service port at the same port number.
+type serviceDocument struct {
+	model.Service
+	Instances []instanceDocument `json:"instances,omitempty"`
+}
+
+type instanceDocument struct {
+	Address string         `json:"address"`
+	Ports   map[string]int `json:"ports,omitempty"`
+	Tags    model.Tags     `json:"tags,omitempty"`
+}
+
+// ParseServices reads a multi-document YAML input into service declarations
+// and their instances. The returned error lists all invalid documents by
+// their position.
+func ParseServices(data []byte) ([]*model.Service, []*model.ServiceInstance, error) {
+	docs, err := splitDocuments(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var errs error
+	services := make([]*model.Service, 0)
+	instances := make([]*model.ServiceInstance, 0)
+	for i, doc := range docs {
+		svc, list, err := parseServiceDocument(doc)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("document %d: %v", i+1, err))
+			continue
+		}
+		services = append(services, svc)
+		instances = append(instances, list...)
+	}
+	return services, instances, errs
+}
+
+func parseServiceDocument(data []byte) (*model.Service, []*model.ServiceInstance, error) {
+	js, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Cannot read YAML input: %v", err)
+	}
+	var doc serviceDocument
+	if err = json.Unmarshal(js, &doc); err != nil {
+		return nil, nil, fmt.Errorf("Cannot parse service: %v", err)
+	}
+	svc := &doc.Service
+	if err = svc.Validate(); err != nil {
+		return nil, nil, multierror.Prefix(err, svc.Hostname+":")
+	}
+
+	var errs error
+	instances := make([]*model.ServiceInstance, 0)
+	for _, item := range doc.Instances {
+		if net.ParseIP(item.Address) == nil {
+			errs = multierror.Append(errs, fmt.Errorf("Invalid instance address %q", item.Address))
+			continue
+		}
+		if len(item.Tags) > 0 {
+			if err = item.Tags.Validate(); err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+		}
+		for name := range item.Ports {
+			if _, exists := svc.Ports.Get(name); !exists {
+				errs = multierror.Append(errs, fmt.Errorf("Instance %s refers to undeclared port %q", item.Address, name))
+			}
+		}
+		for _, port := range svc.Ports {
+			endpointPort := port.Port
+			if len(item.Ports) > 0 {
+				var exists bool
+				if endpointPort, exists = item.Ports[port.Name]; !exists {
+					continue
+				}
+			}
+			if endpointPort <= 0 {
+				errs = multierror.Append(errs, fmt.Errorf("Invalid port %d for instance %s", endpointPort, item.Address))
+				continue
+			}
+			instances = append(instances, &model.ServiceInstance{
+				Endpoint: model.NetworkEndpoint{
+					Address:     item.Address,
+					Port:        endpointPort,
+					ServicePort: port,
+				},
+				Service: svc,
+				Tags:    item.Tags,
+			})
+		}
+	}
+	if errs != nil {
+		return nil, nil, multierror.Prefix(errs, svc.Hostname+":")
+	}
+	return svc, instances, nil
+}
+
+// ServiceController declares services and their instances from the YAML files
+// in a directory and re-scans the directory whenever its files change. Changes
+// between two scans are delivered to the service and instance handlers.
+type ServiceController struct {
+	dir    string
+	period time.Duration
+
+	// store holds the last scanned state and dispatches the events
+	store *memory.Controller
+
+	// mu serializes directory scans
+	mu sync.Mutex
+
+	// files keeps the declarations loaded from each file, so that a file
+	// that fails to parse retains its previous declarations until it is fixed
+	files map[string]serviceFile
+
+	// services and instances are the last applied declarations
+	services  map[string]*model.Service
+	instances map[instanceKey]*model.ServiceInstance
+}
+
+type serviceFile struct {
+	services  []*model.Service
+	instances []*model.ServiceInstance
+}
+
+// instanceKey identifies a service instance by service, endpoint, and service port
+type instanceKey struct {
+	hostname string
+	address  string
+	port     int
+	name     string
+}
+
+func keyOf(instance *model.ServiceInstance) instanceKey {
+	return instanceKey{
+		hostname: instance.Service.Hostname,
+		address:  instance.Endpoint.Address,
+		port:     instance.Endpoint.Port,
+		name:     instance.Endpoint.ServicePort.Name,
+	}
+}
+
+// NewServiceController creates a service registry for a directory that is
+// also re-scanned at the given period in case a file change notification is
+// missed
+func NewServiceController(dir string, period time.Duration) *ServiceController {
+	return &ServiceController{
+		dir:       dir,
+		period:    period,
+		store:     memory.NewController(model.KindMap{}),
+		files:     make(map[string]serviceFile),
+		services:  make(map[string]*model.Service),
+		instances: make(map[instanceKey]*model.ServiceInstance),
+	}
+}
+
+// Scan reads all YAML files in the directory and applies the difference with
+// the previously scanned state to the registry. The returned error lists
+// every invalid file with its path.
+func (c *ServiceController) Scan() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs error
+
+	entries, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]serviceFile)
+	for _, entry := range entries {
+		path := filepath.Join(c.dir, entry.Name())
+		if entry.IsDir() || !isYAML(path) {
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err == nil {
+			var declared serviceFile
+			declared.services, declared.instances, err = ParseServices(data)
+			if err == nil {
+				files[path] = declared
+				continue
+			}
+		}
+		errs = multierror.Append(errs, multierror.Prefix(err, path+":"))
+		if previous, ok := c.files[path]; ok {
+			files[path] = previous
+		}
+	}
+
+	// merge files in the directory order, reporting duplicate services
+	services := make(map[string]*model.Service)
+	instances := make(map[instanceKey]*model.ServiceInstance)
+	origin := make(map[string]string)
+	for _, entry := range entries {
+		path := filepath.Join(c.dir, entry.Name())
+		declared := files[path]
+		for _, svc := range declared.services {
+			if other, exists := origin[svc.Hostname]; exists {
+				errs = multierror.Append(errs,
+					fmt.Errorf("%s: duplicate service %q, already declared in %s", path, svc.Hostname, other))
+				continue
+			}
+			origin[svc.Hostname] = path
+			services[svc.Hostname] = svc
+		}
+		for _, instance := range declared.instances {
+			if origin[instance.Service.Hostname] == path {
+				instances[keyOf(instance)] = instance
+			}
+		}
+	}
+	c.files = files
+
+	if err := c.apply(services, instances); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	return errs
+}
+
+// apply updates the store to match the desired state, emitting events only for changes
+func (c *ServiceController) apply(services map[string]*model.Service,
+	instances map[instanceKey]*model.ServiceInstance) error {
+	var errs error
+
+	// services are added before and removed after their instances
+	for hostname, svc := range services {
+		if old, exists := c.services[hostname]; !exists || !reflect.DeepEqual(old, svc) {
+			if err := c.store.AddService(svc); err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+			c.services[hostname] = svc
+		}
+	}
+	for key, old := range c.instances {
+		if _, exists := instances[key]; !exists {
+			delete(c.instances, key)
+			if _, exists = services[key.hostname]; !exists {
+				// removed together with the service
+				continue
+			}
+			if err := c.store.DeleteInstance(old); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		}
+	}
+	for key, instance := range instances {
+		if old, exists := c.instances[key]; !exists || !reflect.DeepEqual(old, instance) {
+			if err := c.store.AddInstance(instance); err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+			c.instances[key] = instance
+		}
+	}
+	for hostname := range c.services {
+		if _, exists := services[hostname]; !exists {
+			delete(c.services, hostname)
+			if err := c.store.DeleteService(hostname); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// Run scans the directory on every file change until a signal is received
+func (c *ServiceController) Run(stop chan struct{}) {
+	go c.store.Run(stop)
+
+	scan := func() {
+		if err := c.Scan(); err != nil {
+			glog.Warningf("Service directory %s has errors: %v", c.dir, err)
+		}
+	}
+	scan()
+	watch(c.dir, c.period, scan, stop)
+}
+
+// AppendConfigHandler implements a controller operation.
+// The service registry does not hold configuration, and the handler is never called.
+func (c *ServiceController) AppendConfigHandler(kind string, f func(model.Key, proto.Message, model.Event)) error {
+	return nil
+}
+
+// AppendServiceHandler implements a controller operation
+func (c *ServiceController) AppendServiceHandler(f func(*model.Service, model.Event)) error {
+	return c.store.AppendServiceHandler(f)
+}
+
+// AppendInstanceHandler implements a controller operation
+func (c *ServiceController) AppendInstanceHandler(f func(*model.ServiceInstance, model.Event)) error {
+	return c.store.AppendInstanceHandler(f)
+}
+
+// Services implements a service catalog operation
+func (c *ServiceController) Services() []*model.Service {
+	return c.store.Services()
+}
+
+// GetService implements a service catalog operation
+func (c *ServiceController) GetService(hostname string) (*model.Service, bool) {
+	return c.store.GetService(hostname)
+}
+
+// Instances implements a service catalog operation
+func (c *ServiceController) Instances(hostname string, ports []string,
+	tagsList model.TagsList, health model.HealthFilter) []*model.ServiceInstance {
+	return c.store.Instances(hostname, ports, tagsList, health)
+}
+
+// HostInstances implements a service catalog operation
+func (c *ServiceController) HostInstances(addrs map[string]bool) []*model.ServiceInstance {
+	return c.store.HostInstances(addrs)
+}
@@ -0,0 +1,223 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"istio.io/manager/model"
+)
+
+const (
+	services = `
+hostname: db.example.com
+address: 10.1.0.1
+ports:
+- name: http
+  port: 80
+  protocol: HTTP
+- name: grpc
+  port: 90
+  protocol: GRPC
+instances:
+- address: 10.0.0.1
+  tags:
+    version: v1
+- address: 10.0.0.2
+  ports:
+    http: 8080
+  tags:
+    version: v2
+---
+hostname: cache.example.com
+ports:
+- name: tcp
+  port: 6379
+  protocol: TCP
+instances:
+- address: 10.0.0.1
+`
+	updatedServices = `
+hostname: db.example.com
+address: 10.1.0.1
+ports:
+- name: http
+  port: 80
+  protocol: HTTP
+- name: grpc
+  port: 90
+  protocol: GRPC
+instances:
+- address: 10.0.0.1
+  tags:
+    version: v1
+`
+	invalidServices = `
+hostname: db.example.com
+ports:
+- name: http
+  port: 80
+  protocol: HTTP
+instances:
+- address: not-an-ip
+- address: 10.0.0.3
+  ports:
+    missing: 80
+`
+)
+
+func TestParseServices(t *testing.T) {
+	svcs, instances, err := ParseServices([]byte(services))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(svcs) != 2 || svcs[0].Hostname != "db.example.com" || svcs[1].Hostname != "cache.example.com" {
+		t.Errorf("ParseServices() => got services %v", svcs)
+	}
+	if len(instances) != 4 {
+		t.Fatalf("ParseServices() => got %d instances, want 4", len(instances))
+	}
+	if got := instances[2]; got.Endpoint.Address != "10.0.0.2" || got.Endpoint.Port != 8080 ||
+		got.Endpoint.ServicePort.Name != "http" || got.Tags["version"] != "v2" {
+		t.Errorf("ParseServices() => got instance %v", got)
+	}
+
+	_, _, err = ParseServices([]byte(invalidServices))
+	if err == nil {
+		t.Fatal("ParseServices(invalid) => got no error")
+	}
+	for _, want := range []string{"not-an-ip", "missing"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseServices(invalid) => got %v, want an error for %q", err, want)
+		}
+	}
+}
+
+func TestServiceController(t *testing.T) {
+	dir, err := ioutil.TempDir("", "services")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	ctl := NewServiceController(dir, time.Hour)
+	var _ model.Controller = ctl
+	var _ model.ServiceDiscovery = ctl
+
+	var mu sync.Mutex
+	serviceEvents := make(map[model.Event]int)
+	instanceEvents := make(map[model.Event]int)
+	if err = ctl.AppendServiceHandler(func(s *model.Service, ev model.Event) {
+		mu.Lock()
+		serviceEvents[ev]++
+		mu.Unlock()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err = ctl.AppendInstanceHandler(func(s *model.ServiceInstance, ev model.Event) {
+		mu.Lock()
+		instanceEvents[ev]++
+		mu.Unlock()
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, dir, "services.yaml", services)
+
+	// wait for the initial scan to avoid racing with the file updates below
+	stop := make(chan struct{})
+	defer close(stop)
+	go ctl.Run(stop)
+	eventually(func() bool { return len(ctl.Services()) == 2 }, t)
+
+	if err = ctl.Scan(); err != nil {
+		t.Error(err)
+	}
+	if svcs := ctl.Services(); len(svcs) != 2 {
+		t.Errorf("Services() => got %v", svcs)
+	}
+	if _, exists := ctl.GetService("db.example.com"); !exists {
+		t.Error("GetService() => got no service")
+	}
+
+	cases := []struct {
+		ports []string
+		tags  model.TagsList
+		count int
+	}{
+		{[]string{"http"}, nil, 2},
+		{[]string{"http", "grpc"}, nil, 3},
+		{[]string{"grpc"}, model.TagsList{{"version": "v2"}}, 0},
+		{[]string{"http"}, model.TagsList{{"version": "v2"}}, 1},
+		{[]string{"missing"}, nil, 0},
+	}
+	for _, c := range cases {
+		if out := ctl.Instances("db.example.com", c.ports, c.tags, model.AnyHealth); len(out) != c.count {
+			t.Errorf("Instances(%v, %v) => got %d, want %d", c.ports, c.tags, len(out), c.count)
+		}
+	}
+	if out := ctl.HostInstances(map[string]bool{"10.0.0.1": true}); len(out) != 3 {
+		t.Errorf("HostInstances() => got %d, want 3", len(out))
+	}
+
+	// an invalid file is reported by path and the previous declarations are retained
+	writeFile(t, dir, "services.yaml", invalidServices)
+	if err = ctl.Scan(); err == nil || !strings.Contains(err.Error(), "services.yaml") {
+		t.Errorf("Scan() => got %v, want an error for services.yaml", err)
+	}
+	if svcs := ctl.Services(); len(svcs) != 2 {
+		t.Errorf("Services() => got %v, want the previous services", svcs)
+	}
+
+	// removing a service and an instance
+	writeFile(t, dir, "services.yaml", updatedServices)
+	if err = ctl.Scan(); err != nil {
+		t.Error(err)
+	}
+	if svcs := ctl.Services(); len(svcs) != 1 {
+		t.Errorf("Services() => got %v", svcs)
+	}
+	if out := ctl.Instances("db.example.com", []string{"http"}, nil, model.AnyHealth); len(out) != 1 {
+		t.Errorf("Instances() => got %d, want 1", len(out))
+	}
+
+	// rescanning without changes does not produce events
+	if err = ctl.Scan(); err != nil {
+		t.Error(err)
+	}
+
+	expectedServices := map[model.Event]int{model.EventAdd: 2, model.EventDelete: 1}
+	expectedInstances := map[model.Event]int{model.EventAdd: 4, model.EventDelete: 2}
+	eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		for ev, n := range expectedServices {
+			if serviceEvents[ev] != n {
+				return false
+			}
+		}
+		for ev, n := range expectedInstances {
+			if instanceEvents[ev] != n {
+				return false
+			}
+		}
+		return serviceEvents[model.EventUpdate] == 0 && instanceEvents[model.EventUpdate] == 0
+	}, t)
+}
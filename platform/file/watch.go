@@ -0,0 +1,70 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang/glog"
+)
+
+// eventDelay coalesces the bursts of file system events that editors and
+// configuration management tools produce for a single change
+const eventDelay = 100 * time.Millisecond
+
+// watch calls scan after every change to the files in the directory until a
+// signal is received. The directory is also re-scanned at the given period
+// in case a notification is missed, e.g. on network file systems, and scans
+// fall back to the period alone if the directory cannot be watched.
+func watch(dir string, period time.Duration, scan func(), stop chan struct{}) {
+	var events <-chan fsnotify.Event
+	var errors <-chan error
+	if watcher, err := fsnotify.NewWatcher(); err != nil {
+		glog.Warningf("Cannot watch directory %s, polling every %v: %v", dir, period, err)
+	} else if err = watcher.Add(dir); err != nil {
+		glog.Warningf("Cannot watch directory %s, polling every %v: %v", dir, period, err)
+		_ = watcher.Close()
+	} else {
+		defer func() { _ = watcher.Close() }()
+		events = watcher.Events
+		errors = watcher.Errors
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	// pending fires once the events quiet down, and is nil otherwise
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-stop:
+			glog.V(2).Info("Controller terminated")
+			return
+		case event := <-events:
+			glog.V(2).Infof("File system event: %v", event)
+			if pending == nil {
+				pending = time.After(eventDelay)
+			}
+		case err := <-errors:
+			glog.Warningf("Error watching directory %s: %v", dir, err)
+		case <-pending:
+			pending = nil
+			scan()
+		case <-ticker.C:
+			scan()
+		}
+	}
+}
@@ -0,0 +1,384 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package history provides a configuration registry wrapper that records
+// every change in a local journal and restores objects to a point in time.
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	multierror "github.com/hashicorp/go-multierror"
+
+	"istio.io/manager/model"
+)
+
+// Entry is a recorded configuration change
+type Entry struct {
+	Key model.Key
+	// Time of the change
+	Time time.Time
+	// Actor that made the change
+	Actor string
+	// Previous content of the object, nil if the object has been created
+	Previous proto.Message
+	// PreviousUnknown is true if the previous content could not be read
+	// consistently with the change, e.g. due to a concurrent writer
+	PreviousUnknown bool
+	// Current content of the object, nil if the object has been deleted
+	Current proto.Message
+}
+
+// record is the JSON representation of an entry in the journal file
+type record struct {
+	Kind      string          `json:"kind"`
+	Name      string          `json:"name"`
+	Namespace string          `json:"namespace"`
+	Time      time.Time       `json:"time"`
+	Actor     string          `json:"actor"`
+	Version   string          `json:"version,omitempty"`
+	Previous  json.RawMessage `json:"previous,omitempty"`
+	Unknown   bool            `json:"previous_unknown,omitempty"`
+	Current   json.RawMessage `json:"current,omitempty"`
+}
+
+// journal is the change log shared by all views of a registry
+type journal struct {
+	// writes serializes the changes made through the registry, so that the
+	// previous content read before a change is not changed by another view
+	writes sync.Mutex
+
+	mu      sync.Mutex
+	path    string
+	entries []Entry
+}
+
+// Registry wraps a configuration registry and records every change made
+// through it on behalf of an actor. The journal is kept in memory and
+// appended to a local file with one JSON record per line, so that the
+// history survives restarts.
+type Registry struct {
+	model.ConfigRegistry
+	mapping model.KindMap
+	actor   string
+	journal *journal
+
+	// now returns the change timestamps
+	now func() time.Time
+}
+
+// NewRegistry wraps a registry for an actor and loads the history from the
+// journal file. The file is created on the first recorded change.
+func NewRegistry(registry model.ConfigRegistry, mapping model.KindMap, path, actor string) (*Registry, error) {
+	j := &journal{path: path}
+	if err := j.load(mapping); err != nil {
+		return nil, err
+	}
+	return &Registry{
+		ConfigRegistry: registry,
+		mapping:        mapping,
+		actor:          actor,
+		journal:        j,
+		now:            time.Now,
+	}, nil
+}
+
+// As returns a view of the registry that records changes on behalf of another actor
+func (r *Registry) As(actor string) *Registry {
+	out := *r
+	out.actor = actor
+	return &out
+}
+
+// Post implements a registry operation
+func (r *Registry) Post(key model.Key, v proto.Message) (string, error) {
+	r.journal.writes.Lock()
+	defer r.journal.writes.Unlock()
+	revision, err := r.ConfigRegistry.Post(key, v)
+	if err == nil {
+		r.record(key, nil, false, v)
+	}
+	return revision, err
+}
+
+// Put implements a registry operation. The previous content is recorded only
+// if it is the revision that the update replaces, since another writer may
+// change the object between the read and the update.
+func (r *Registry) Put(key model.Key, v proto.Message, revision string) (string, error) {
+	r.journal.writes.Lock()
+	defer r.journal.writes.Unlock()
+	previous, exists, current := r.ConfigRegistry.Get(key)
+	known := exists && current == revision
+	if !known {
+		glog.Warningf("Cannot read revision %s of %v, recording an unknown previous state", revision, key)
+		previous = nil
+	}
+	next, err := r.ConfigRegistry.Put(key, v, revision)
+	if err == nil {
+		r.record(key, previous, !known, v)
+	}
+	return next, err
+}
+
+// Delete implements a registry operation
+func (r *Registry) Delete(key model.Key) error {
+	r.journal.writes.Lock()
+	defer r.journal.writes.Unlock()
+	previous, exists, _ := r.ConfigRegistry.Get(key)
+	if !exists {
+		glog.Warningf("Cannot read %v before the delete, recording an unknown previous state", key)
+	}
+	err := r.ConfigRegistry.Delete(key)
+	if err == nil {
+		r.record(key, previous, !exists, nil)
+	}
+	return err
+}
+
+// record appends a change to the journal. A failure to persist the change is
+// logged and does not fail the registry operation, which has already succeeded.
+func (r *Registry) record(key model.Key, previous proto.Message, unknown bool, current proto.Message) {
+	entry := Entry{
+		Key:             key,
+		Time:            r.now(),
+		Actor:           r.actor,
+		Previous:        previous,
+		PreviousUnknown: unknown,
+		Current:         current,
+	}
+	if err := r.journal.append(r.mapping, entry); err != nil {
+		glog.Warningf("Cannot record the history of %v: %v", key, err)
+	}
+}
+
+// History lists the recorded changes of an object in the order they were made
+func (r *Registry) History(key model.Key) []Entry {
+	r.journal.mu.Lock()
+	defer r.journal.mu.Unlock()
+	out := make([]Entry, 0)
+	for _, entry := range r.journal.entries {
+		if entry.Key == key {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+// StateAt returns the content of an object at a point in time, bool indicates existence.
+// An object without recorded changes is assumed to be unchanged. The state
+// before the first recorded change is unknown if the change could not read it.
+func (r *Registry) StateAt(key model.Key, t time.Time) (proto.Message, bool, error) {
+	entries := r.History(key)
+	if len(entries) == 0 {
+		out, exists, _ := r.ConfigRegistry.Get(key)
+		return out, exists, nil
+	}
+	var out proto.Message
+	if entries[0].Time.After(t) {
+		if entries[0].PreviousUnknown {
+			return nil, false, fmt.Errorf("Unknown state of %v before %v", key, entries[0].Time)
+		}
+		out = entries[0].Previous
+	}
+	for _, entry := range entries {
+		if entry.Time.After(t) {
+			break
+		}
+		out = entry.Current
+	}
+	return out, out != nil, nil
+}
+
+// Restore reverts an object to its state at a point in time.
+// The restore is recorded in the history like any other change.
+func (r *Registry) Restore(key model.Key, t time.Time) error {
+	target, existed, err := r.StateAt(key, t)
+	if err != nil {
+		return err
+	}
+	current, exists, revision := r.ConfigRegistry.Get(key)
+	switch {
+	case !existed && exists:
+		return r.Delete(key)
+	case existed && !exists:
+		_, err := r.Post(key, target)
+		return err
+	case existed && exists && !proto.Equal(target, current):
+		_, err := r.Put(key, target, revision)
+		return err
+	default:
+		return nil
+	}
+}
+
+// RestoreNamespace reverts all objects with recorded changes in a namespace
+// to their state at a point in time
+func (r *Registry) RestoreNamespace(namespace string, t time.Time) error {
+	r.journal.mu.Lock()
+	seen := make(map[model.Key]bool)
+	keys := make([]model.Key, 0)
+	for _, entry := range r.journal.entries {
+		if entry.Key.Namespace == namespace && !seen[entry.Key] {
+			seen[entry.Key] = true
+			keys = append(keys, entry.Key)
+		}
+	}
+	r.journal.mu.Unlock()
+
+	sort.Sort(keysByName(keys))
+	var errs error
+	for _, key := range keys {
+		if err := r.Restore(key, t); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("Cannot restore %v: %v", key, err))
+		}
+	}
+	return errs
+}
+
+// keysByName sorts keys by kind and name
+type keysByName []model.Key
+
+func (s keysByName) Len() int {
+	return len(s)
+}
+
+func (s keysByName) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s keysByName) Less(i, j int) bool {
+	if s[i].Kind != s[j].Kind {
+		return s[i].Kind < s[j].Kind
+	}
+	return s[i].Name < s[j].Name
+}
+
+// load reads the journal file if it exists
+func (j *journal) load(mapping model.KindMap) error {
+	data, err := ioutil.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var rec record
+		if err = json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("%s:%d: %v", j.path, i+1, err)
+		}
+		entry, err := fromRecord(mapping, &rec)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", j.path, i+1, err)
+		}
+		j.entries = append(j.entries, *entry)
+	}
+	return nil
+}
+
+// append persists an entry and then adds it to the journal, so that the
+// journal in memory never differs from the file
+func (j *journal) append(mapping model.KindMap, entry Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	rec, err := toRecord(mapping, &entry)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	j.entries = append(j.entries, entry)
+	return nil
+}
+
+func toRecord(mapping model.KindMap, entry *Entry) (*record, error) {
+	schema, ok := mapping[entry.Key.Kind]
+	if !ok {
+		return nil, fmt.Errorf("Undeclared kind: %q", entry.Key.Kind)
+	}
+	rec := &record{
+		Kind:      entry.Key.Kind,
+		Name:      entry.Key.Name,
+		Namespace: entry.Key.Namespace,
+		Time:      entry.Time,
+		Actor:     entry.Actor,
+		Version:   schema.Version,
+		Unknown:   entry.PreviousUnknown,
+	}
+	if entry.Previous != nil {
+		js, err := schema.ToJSON(entry.Previous)
+		if err != nil {
+			return nil, err
+		}
+		rec.Previous = json.RawMessage(js)
+	}
+	if entry.Current != nil {
+		js, err := schema.ToJSON(entry.Current)
+		if err != nil {
+			return nil, err
+		}
+		rec.Current = json.RawMessage(js)
+	}
+	return rec, nil
+}
+
+func fromRecord(mapping model.KindMap, rec *record) (*Entry, error) {
+	schema, ok := mapping[rec.Kind]
+	if !ok {
+		return nil, fmt.Errorf("Undeclared kind: %q", rec.Kind)
+	}
+	entry := &Entry{
+		Key:             model.Key{Kind: rec.Kind, Name: rec.Name, Namespace: rec.Namespace},
+		Time:            rec.Time,
+		Actor:           rec.Actor,
+		PreviousUnknown: rec.Unknown,
+	}
+	var err error
+	if len(rec.Previous) > 0 {
+		if entry.Previous, err = schema.FromJSONVersion(rec.Version, string(rec.Previous)); err != nil {
+			return nil, err
+		}
+	}
+	if len(rec.Current) > 0 {
+		if entry.Current, err = schema.FromJSONVersion(rec.Version, string(rec.Current)); err != nil {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"istio.io/manager/model"
+	"istio.io/manager/platform/memory"
+	"istio.io/manager/test/mock"
+)
+
+func makeRegistry(t *testing.T, store model.ConfigRegistry, path string, clock *time.Time) *Registry {
+	r, err := NewRegistry(store, mock.Mapping, path, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.now = func() time.Time {
+		*clock = clock.Add(time.Minute)
+		return *clock
+	}
+	return r
+}
+
+func TestRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, "history.json")
+
+	store := memory.NewController(mock.Mapping)
+	clock := time.Date(2017, time.March, 1, 0, 0, 0, 0, time.UTC)
+	start := clock
+	mock.CheckMapInvariant(makeRegistry(t, store, path, &clock), t, "invariant", 3)
+
+	r := makeRegistry(t, store, path, &clock)
+	other := model.Key{Kind: mock.Kind, Name: "other", Namespace: mock.Namespace}
+	if _, err = r.Post(other, mock.Make(-1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// create, update, and delete an object
+	revision, err := r.Post(mock.Key, mock.Make(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := clock.Add(30 * time.Second)
+	if _, err = r.As("bob").Put(mock.Key, mock.Make(1), revision); err != nil {
+		t.Fatal(err)
+	}
+	updated := clock.Add(30 * time.Second)
+	if err = r.Delete(mock.Key); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := r.History(mock.Key)
+	if len(entries) != 3 {
+		t.Fatalf("History() => got %d entries, want 3", len(entries))
+	}
+	if entries[1].Actor != "bob" || !reflect.DeepEqual(entries[1].Previous, mock.Make(0)) ||
+		!reflect.DeepEqual(entries[1].Current, mock.Make(1)) {
+		t.Errorf("History() => got %v for the update", entries[1])
+	}
+	if entries[2].Actor != "alice" || entries[2].Current != nil {
+		t.Errorf("History() => got %v for the delete", entries[2])
+	}
+
+	// the history survives a restart
+	r = makeRegistry(t, store, path, &clock)
+	if reloaded := r.History(mock.Key); !reflect.DeepEqual(reloaded, entries) {
+		t.Errorf("History() after reload => got %v, want %v", reloaded, entries)
+	}
+
+	if _, exists, err := r.StateAt(mock.Key, start); exists || err != nil {
+		t.Errorf("StateAt(before creation) => got an object or error %v", err)
+	}
+	if v, _, err := r.StateAt(mock.Key, updated); !reflect.DeepEqual(v, mock.Make(1)) || err != nil {
+		t.Errorf("StateAt(after update) => got %v, %v", v, err)
+	}
+
+	// restore a deleted object
+	if err = r.Restore(mock.Key, created); err != nil {
+		t.Error(err)
+	}
+	if v, _, _ := store.Get(mock.Key); !reflect.DeepEqual(v, mock.Make(0)) {
+		t.Errorf("Get() after restore => got %v, want %v", v, mock.Make(0))
+	}
+	if len(r.History(mock.Key)) != 4 {
+		t.Error("Restore() => the restore is not recorded")
+	}
+
+	// restore the namespace to the state before the changes
+	if err = r.RestoreNamespace(mock.Namespace, start); err != nil {
+		t.Error(err)
+	}
+	if list, _ := store.List(mock.Kind, mock.Namespace); len(list) != 0 {
+		t.Errorf("List() after namespace restore => got %v, want none", list)
+	}
+}
+
+// unversioned is a registry that overwrites objects regardless of the revision
+type unversioned struct {
+	model.ConfigRegistry
+}
+
+func (u unversioned) Put(key model.Key, v proto.Message, _ string) (string, error) {
+	_, _, revision := u.ConfigRegistry.Get(key)
+	return u.ConfigRegistry.Put(key, v, revision)
+}
+
+func TestRegistryUnknownPrevious(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	store := memory.NewController(mock.Mapping)
+	clock := time.Date(2017, time.March, 1, 0, 0, 0, 0, time.UTC)
+	start := clock
+	r := makeRegistry(t, unversioned{store}, filepath.Join(dir, "history.json"), &clock)
+	if _, err = store.Post(mock.Key, mock.Make(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the update does not replace the revision it was made against
+	if _, err = r.Put(mock.Key, mock.Make(1), "stale"); err != nil {
+		t.Fatal(err)
+	}
+	entries := r.History(mock.Key)
+	if len(entries) != 1 || !entries[0].PreviousUnknown || entries[0].Previous != nil {
+		t.Fatalf("History() => got %v, want an unknown previous state", entries)
+	}
+	if _, _, err = r.StateAt(mock.Key, start); err == nil {
+		t.Error("StateAt(before update) => got no error")
+	}
+	if err = r.Restore(mock.Key, start); err == nil {
+		t.Error("Restore(before update) => got no error")
+	}
+	if v, _, _ := store.Get(mock.Key); !reflect.DeepEqual(v, mock.Make(1)) {
+		t.Errorf("Get() after failed restore => got %v, want %v", v, mock.Make(1))
+	}
+}
+
+func TestRegistryWriteFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	clock := time.Date(2017, time.March, 1, 0, 0, 0, 0, time.UTC)
+	r := makeRegistry(t, memory.NewController(mock.Mapping), filepath.Join(dir, "missing", "history.json"), &clock)
+	if _, err = r.Post(mock.Key, mock.Make(0)); err != nil {
+		t.Fatal(err)
+	}
+	if entries := r.History(mock.Key); len(entries) != 0 {
+		t.Errorf("History() after a failed write => got %v, want none", entries)
+	}
+}
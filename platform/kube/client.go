@@ -214,10 +214,10 @@ func (cl *Client) DeregisterResources() error {
 }
 
 // Get implements registry operation
-func (cl *Client) Get(key model.Key) (proto.Message, bool) {
+func (cl *Client) Get(key model.Key) (proto.Message, bool, string) {
 	if err := cl.mapping.ValidateKey(&key); err != nil {
 		glog.Warning(err)
-		return nil, false
+		return nil, false, ""
 	}
 
 	config := &Config{}
@@ -229,29 +229,60 @@ func (cl *Client) Get(key model.Key) (proto.Message, bool) {
 
 	if err != nil {
 		glog.Warning(err)
-		return nil, false
+		return nil, false, ""
 	}
 
 	kind := cl.mapping[key.Kind]
-	out, err := kind.FromJSONMap(config.Spec)
+	out, err := kind.FromJSONMapVersion(config.Metadata.Annotations[SchemaVersionAnnotation], config.Spec)
 	if err != nil {
 		glog.Warning(err)
-		return nil, false
+		return nil, false, ""
 	}
-	return out, true
+	return out, true, config.Metadata.ResourceVersion
 }
 
-// Put implements registry operation
-func (cl *Client) Put(k model.Key, v proto.Message) error {
+// Post implements registry operation
+func (cl *Client) Post(k model.Key, v proto.Message) (string, error) {
 	out, err := modelToKube(cl.mapping, &k, v)
 	if err != nil {
-		return err
+		return "", err
 	}
-	return cl.dyn.Post().
+
+	config := &Config{}
+	err = cl.dyn.Post().
 		Namespace(k.Namespace).
 		Resource(IstioKind + "s").
 		Body(out).
-		Do().Error()
+		Do().Into(config)
+	if err != nil {
+		return "", convertError(k, "", err)
+	}
+	return config.Metadata.ResourceVersion, nil
+}
+
+// Put implements registry operation.
+// The revision is the resource version of the third-party resource.
+func (cl *Client) Put(k model.Key, v proto.Message, revision string) (string, error) {
+	out, err := modelToKube(cl.mapping, &k, v)
+	if err != nil {
+		return "", err
+	}
+	if revision == "" {
+		return "", &model.ConflictError{Key: k, Revision: revision}
+	}
+	out.Metadata.ResourceVersion = revision
+
+	config := &Config{}
+	err = cl.dyn.Put().
+		Namespace(k.Namespace).
+		Resource(IstioKind + "s").
+		Name(configKey(&k)).
+		Body(out).
+		Do().Into(config)
+	if err != nil {
+		return "", convertError(k, revision, err)
+	}
+	return config.Metadata.ResourceVersion, nil
 }
 
 // Delete implements registry operation
@@ -260,15 +291,19 @@ func (cl *Client) Delete(key model.Key) error {
 		return err
 	}
 
-	return cl.dyn.Delete().
+	err := cl.dyn.Delete().
 		Namespace(key.Namespace).
 		Resource(IstioKind + "s").
 		Name(configKey(&key)).
 		Do().Error()
+	if err != nil {
+		return convertError(key, "", err)
+	}
+	return nil
 }
 
 // List implements registry operation
-func (cl *Client) List(kind, namespace string) (map[model.Key]proto.Message, error) {
+func (cl *Client) List(kind, namespace string) ([]model.Config, error) {
 	if _, ok := cl.mapping[kind]; !ok {
 		return nil, fmt.Errorf("Missing kind %q", kind)
 	}
@@ -279,24 +314,43 @@ func (cl *Client) List(kind, namespace string) (map[model.Key]proto.Message, err
 		Resource(IstioKind + "s").
 		Do().Into(list)
 
-	out := make(map[model.Key]proto.Message, 0)
+	out := make([]model.Config, 0)
 	for _, item := range list.Items {
 		name, ns, istioKind, data, err := cl.convertConfig(&item)
 		if kind == istioKind {
 			if err != nil {
 				errs = multierror.Append(errs, err)
 			} else {
-				out[model.Key{
-					Name:      name,
-					Namespace: ns,
-					Kind:      kind,
-				}] = data
+				out = append(out, model.Config{
+					Key: model.Key{
+						Name:      name,
+						Namespace: ns,
+						Kind:      kind,
+					},
+					Revision: item.Metadata.ResourceVersion,
+					Version:  storedVersion(cl.mapping[kind], &item),
+					Content:  data,
+				})
 			}
 		}
 	}
 	return out, errs
 }
 
+// convertError translates Kubernetes API status errors to registry errors
+func convertError(key model.Key, revision string, err error) error {
+	switch {
+	case errors.IsNotFound(err):
+		return &model.NotFoundError{Key: key}
+	case errors.IsAlreadyExists(err):
+		return &model.AlreadyExistsError{Key: key}
+	case errors.IsConflict(err):
+		return &model.ConflictError{Key: key, Revision: revision}
+	default:
+		return err
+	}
+}
+
 // configKey assigns k8s TPR name to Istio config
 func configKey(k *model.Key) string {
 	return k.Kind + "-" + k.Name
@@ -309,7 +363,7 @@ func (cl *Client) convertConfig(item *Config) (name, namespace, kind string, dat
 			kind = k
 			name = strings.TrimPrefix(item.Metadata.Name, kind+"-")
 			namespace = item.Metadata.Namespace
-			data, err = v.FromJSONMap(item.Spec)
+			data, err = v.FromJSONMapVersion(item.Metadata.Annotations[SchemaVersionAnnotation], item.Spec)
 			return
 		}
 	}
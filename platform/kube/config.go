@@ -23,6 +23,9 @@ import (
 	"k8s.io/client-go/pkg/runtime/schema"
 )
 
+// SchemaVersionAnnotation records the schema version of the config spec
+const SchemaVersionAnnotation = "istio.io/schema-version"
+
 // Config is the generic Kubernetes API object wrapper
 type Config struct {
 	v1.TypeMeta `json:",inline"`
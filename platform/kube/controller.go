@@ -45,6 +45,7 @@ type Controller struct {
 	services  cacheHandler
 	endpoints cacheHandler
 	ingresses cacheHandler
+	nodes     cacheHandler
 
 	pods *PodCache
 }
@@ -99,6 +100,16 @@ func NewController(
 			return client.client.ExtensionsV1beta1().Ingresses(namespace).Watch(opts)
 		})
 
+	// nodes are not namespaced, and the node localities are best-effort since
+	// the controller may not be permitted to list the nodes
+	out.nodes = out.createInformer(&v1.Node{}, resyncPeriod,
+		func(opts v1.ListOptions) (runtime.Object, error) {
+			return client.client.Nodes().List(opts)
+		},
+		func(opts v1.ListOptions) (watch.Interface, error) {
+			return client.client.Nodes().Watch(opts)
+		})
+
 	// add stores for TPR kinds
 	for _, kind := range []string{IstioKind} {
 		out.kinds[kind] = out.createInformer(&Config{}, resyncPeriod,
@@ -221,7 +232,9 @@ func (c *Controller) appendIngressConfigHandler(k string, f func(model.Key, prot
 	return nil
 }
 
-// HasSynced returns true after the initial state synchronization
+// HasSynced returns true after the initial state synchronization.
+// The nodes are not waited for, since they only provide the localities of the
+// instances, and the instance handlers are notified once the nodes are known.
 func (c *Controller) HasSynced() bool {
 	if !c.services.informer.HasSynced() ||
 		!c.endpoints.informer.HasSynced() ||
@@ -245,6 +258,7 @@ func (c *Controller) Run(stop chan struct{}) {
 	go c.endpoints.informer.Run(stop)
 	go c.pods.informer.Run(stop)
 	go c.ingresses.informer.Run(stop)
+	go c.nodes.informer.Run(stop)
 	for _, ctl := range c.kinds {
 		go ctl.informer.Run(stop)
 	}
@@ -262,7 +276,7 @@ func keyFunc(name, namespace string) string {
 }
 
 // Get implements a registry operation
-func (c *Controller) Get(key model.Key) (proto.Message, bool) {
+func (c *Controller) Get(key model.Key) (proto.Message, bool, string) {
 	switch key.Kind {
 	case model.IngressRule:
 		return c.getIngress(key)
@@ -271,75 +285,86 @@ func (c *Controller) Get(key model.Key) (proto.Message, bool) {
 	}
 }
 
-func (c *Controller) getTPR(key model.Key) (proto.Message, bool) {
+func (c *Controller) getTPR(key model.Key) (proto.Message, bool, string) {
 	if err := c.client.mapping.ValidateKey(&key); err != nil {
 		glog.Warning(err)
-		return nil, false
+		return nil, false, ""
 	}
 
 	store := c.kinds[IstioKind].informer.GetStore()
 	data, exists, err := store.GetByKey(key.Namespace + "/" + configKey(&key))
 	if !exists {
-		return nil, false
+		return nil, false, ""
 	}
 	if err != nil {
 		glog.Warning(err)
-		return nil, false
+		return nil, false, ""
 	}
 
 	config, ok := data.(*Config)
 	if !ok {
 		glog.Warning("Cannot convert to config from store")
-		return nil, false
+		return nil, false, ""
 	}
 
 	kind := c.client.mapping[key.Kind]
-	out, err := kind.FromJSONMap(config.Spec)
+	out, err := kind.FromJSONMapVersion(config.Metadata.Annotations[SchemaVersionAnnotation], config.Spec)
 	if err != nil {
 		glog.Warning(err)
-		return nil, false
+		return nil, false, ""
 	}
-	return out, true
+	return out, true, config.Metadata.ResourceVersion
 }
 
-func (c *Controller) getIngress(key model.Key) (proto.Message, bool) {
+func (c *Controller) getIngress(key model.Key) (proto.Message, bool, string) {
 	ingressName, _, _, err := decodeIngressRuleName(key.Name)
 	if err != nil {
 		glog.V(2).Infof("getIngress(%s) => error %v", key.String(), err)
-		return nil, false
+		return nil, false, ""
 	}
 	storeKey := keyFunc(ingressName, key.Namespace)
 
 	obj, exists, err := c.ingresses.informer.GetStore().GetByKey(storeKey)
 	if err != nil {
 		glog.V(2).Infof("getIngress(%s) => error %v", key.String(), err)
-		return nil, false
+		return nil, false, ""
 	}
 	if !exists {
-		return nil, false
+		return nil, false, ""
 	}
 
-	messages := convertIngress(*obj.(*v1beta1.Ingress), c.serviceByKey)
+	ingress := obj.(*v1beta1.Ingress)
+	messages := convertIngress(*ingress, c.serviceByKey)
 	message, exists := messages[key]
-	return message, exists
+	return message, exists, ingress.ResourceVersion
+}
+
+// Post implements a registry operation
+func (c *Controller) Post(key model.Key, val proto.Message) (string, error) {
+	switch key.Kind {
+	case model.IngressRule:
+		return "", fmt.Errorf("unsupported operation: cannot post a config element of kind '%s'", key.Kind)
+	default:
+		return c.client.Post(key, val)
+	}
 }
 
 // Put implements a registry operation
-func (c *Controller) Put(key model.Key, val proto.Message) error {
+func (c *Controller) Put(key model.Key, val proto.Message, revision string) (string, error) {
 	switch key.Kind {
 	case model.IngressRule:
-		return c.putIngress(key, val)
+		return c.putIngress(key, val, revision)
 	default:
-		return c.putTPR(key, val)
+		return c.putTPR(key, val, revision)
 	}
 }
 
-func (c *Controller) putTPR(key model.Key, val proto.Message) error {
-	return c.client.Put(key, val)
+func (c *Controller) putTPR(key model.Key, val proto.Message, revision string) (string, error) {
+	return c.client.Put(key, val, revision)
 }
 
-func (c *Controller) putIngress(key model.Key, val proto.Message) error {
-	return fmt.Errorf("unsupported operation: cannot put a config element of kind '%s'", key.Kind)
+func (c *Controller) putIngress(key model.Key, val proto.Message, revision string) (string, error) {
+	return "", fmt.Errorf("unsupported operation: cannot put a config element of kind '%s'", key.Kind)
 }
 
 // Delete implements a registry operation
@@ -361,7 +386,7 @@ func (c *Controller) deleteIngress(key model.Key) error {
 }
 
 // List implements a registry operation
-func (c *Controller) List(kind, namespace string) (map[model.Key]proto.Message, error) {
+func (c *Controller) List(kind, namespace string) ([]model.Config, error) {
 	switch kind {
 	case model.IngressRule:
 		return c.listIngresses(kind, namespace)
@@ -370,13 +395,13 @@ func (c *Controller) List(kind, namespace string) (map[model.Key]proto.Message,
 	}
 }
 
-func (c *Controller) listTPRs(kind, namespace string) (map[model.Key]proto.Message, error) {
+func (c *Controller) listTPRs(kind, namespace string) ([]model.Config, error) {
 	if _, ok := c.client.mapping[kind]; !ok {
 		return nil, fmt.Errorf("Missing kind %q", kind)
 	}
 
 	var errs error
-	out := make(map[model.Key]proto.Message, 0)
+	out := make([]model.Config, 0)
 	for _, data := range c.kinds[IstioKind].informer.GetStore().List() {
 		item, ok := data.(*Config)
 		if ok && (namespace == "" || item.Metadata.Namespace == namespace) {
@@ -385,11 +410,16 @@ func (c *Controller) listTPRs(kind, namespace string) (map[model.Key]proto.Messa
 				if err != nil {
 					errs = multierror.Append(errs, err)
 				} else {
-					out[model.Key{
-						Name:      name,
-						Namespace: ns,
-						Kind:      kind,
-					}] = data
+					out = append(out, model.Config{
+						Key: model.Key{
+							Name:      name,
+							Namespace: ns,
+							Kind:      kind,
+						},
+						Revision: item.Metadata.ResourceVersion,
+						Version:  storedVersion(c.client.mapping[kind], item),
+						Content:  data,
+					})
 				}
 			}
 		}
@@ -397,15 +427,15 @@ func (c *Controller) listTPRs(kind, namespace string) (map[model.Key]proto.Messa
 	return out, errs
 }
 
-func (c *Controller) listIngresses(kind, namespace string) (map[model.Key]proto.Message, error) {
-	out := make(map[model.Key]proto.Message, 0)
+func (c *Controller) listIngresses(kind, namespace string) ([]model.Config, error) {
+	out := make([]model.Config, 0)
 
 	for _, obj := range c.ingresses.informer.GetStore().List() {
 		ingress, ok := obj.(*v1beta1.Ingress)
 		if ok && (namespace == "" || ingress.GetObjectMeta().GetNamespace() == namespace) {
 			ingressRules := convertIngress(*ingress, c.serviceByKey)
 			for key, message := range ingressRules {
-				out[key] = message
+				out = append(out, model.Config{Key: key, Revision: ingress.ResourceVersion, Content: message})
 			}
 		}
 	}
@@ -451,7 +481,8 @@ func (c *Controller) serviceByKey(name, namespace string) (*v1.Service, bool) {
 }
 
 // Instances implements a service catalog operation
-func (c *Controller) Instances(hostname string, ports []string, tagsList model.TagsList) []*model.ServiceInstance {
+func (c *Controller) Instances(hostname string, ports []string, tagsList model.TagsList,
+	health model.HealthFilter) []*model.ServiceInstance {
 	// Get actual service by name
 	name, namespace, err := parseHostname(hostname)
 	if err != nil {
@@ -506,7 +537,12 @@ func (c *Controller) Instances(hostname string, ports []string, tagsList model.T
 		if ep.Name == name && ep.Namespace == namespace {
 			var out []*model.ServiceInstance
 			for _, ss := range ep.Subsets {
-				for _, ea := range ss.Addresses {
+				for _, ea := range convertAddresses(ss) {
+					// the addresses that fail the readiness checks are unhealthy
+					if health == model.HealthyOnly && ea.health == model.Unhealthy {
+						continue
+					}
+
 					tags, _ := c.pods.tagsByIP(ea.IP)
 
 					// check that one of the input tags is a subset of the tags
@@ -522,9 +558,12 @@ func (c *Controller) Instances(hostname string, ports []string, tagsList model.T
 									Address:     ea.IP,
 									Port:        int(port.Port),
 									ServicePort: svcPort,
+									Locality:    c.localityByIP(ea.IP),
+									Weight:      c.pods.weightByIP(ea.IP),
 								},
 								Service: svc,
 								Tags:    tags,
+								Health:  ea.health,
 							})
 						}
 					}
@@ -540,32 +579,44 @@ func (c *Controller) Instances(hostname string, ports []string, tagsList model.T
 func (c *Controller) HostInstances(addrs map[string]bool) []*model.ServiceInstance {
 	var out []*model.ServiceInstance
 	for _, item := range c.endpoints.informer.GetStore().List() {
-		ep := *item.(*v1.Endpoints)
-		for _, ss := range ep.Subsets {
-			for _, ea := range ss.Addresses {
-				if addrs[ea.IP] {
-					for _, port := range ss.Ports {
-						item, exists := c.serviceByKey(ep.Name, ep.Namespace)
-						if !exists {
-							continue
-						}
-						svc := convertService(*item)
-						svcPort, exists := svc.Ports.Get(port.Name)
-						if !exists {
-							continue
-						}
-						tags, _ := c.pods.tagsByIP(ea.IP)
-						out = append(out, &model.ServiceInstance{
-							Endpoint: model.NetworkEndpoint{
-								Address:     ea.IP,
-								Port:        int(port.Port),
-								ServicePort: svcPort,
-							},
-							Service: svc,
-							Tags:    tags,
-						})
-					}
+		out = append(out, c.endpointInstances(item.(*v1.Endpoints), addrs)...)
+	}
+	return out
+}
+
+// endpointInstances lists the service instances of the endpoints of a service
+// with one of the addresses, or all instances if addrs is nil
+func (c *Controller) endpointInstances(ep *v1.Endpoints, addrs map[string]bool) []*model.ServiceInstance {
+	item, exists := c.serviceByKey(ep.Name, ep.Namespace)
+	if !exists {
+		return nil
+	}
+	svc := convertService(*item)
+
+	var out []*model.ServiceInstance
+	for _, ss := range ep.Subsets {
+		for _, ea := range convertAddresses(ss) {
+			if addrs != nil && !addrs[ea.IP] {
+				continue
+			}
+			tags, _ := c.pods.tagsByIP(ea.IP)
+			for _, port := range ss.Ports {
+				svcPort, exists := svc.Ports.Get(port.Name)
+				if !exists {
+					continue
 				}
+				out = append(out, &model.ServiceInstance{
+					Endpoint: model.NetworkEndpoint{
+						Address:     ea.IP,
+						Port:        int(port.Port),
+						ServicePort: svcPort,
+						Locality:    c.localityByIP(ea.IP),
+						Weight:      c.pods.weightByIP(ea.IP),
+					},
+					Service: svc,
+					Tags:    tags,
+					Health:  ea.health,
+				})
 			}
 		}
 	}
@@ -581,23 +632,82 @@ func (c *Controller) AppendServiceHandler(f func(*model.Service, model.Event)) e
 	return nil
 }
 
-// AppendInstanceHandler implements a service catalog operation
+// AppendInstanceHandler implements a service catalog operation.
+// Each endpoints event is translated to the events of the service instances
+// that are added, updated or deleted since the previous endpoints event.
+// The instances also change with the labels of their pods and the localities
+// of their nodes, so the pod and node events re-evaluate the endpoints with
+// the addresses of the affected pods.
 func (c *Controller) AppendInstanceHandler(f func(*model.ServiceInstance, model.Event)) error {
+	// known instances by endpoints key, private to the handler. The handlers
+	// are serialized by the queue.
+	known := make(map[string]map[string]*model.ServiceInstance)
+	update := func(ep *v1.Endpoints, deleted bool) {
+		key := keyFunc(ep.Name, ep.Namespace)
+		current := make(map[string]*model.ServiceInstance)
+		if !deleted {
+			current = indexInstances(c.endpointInstances(ep, nil))
+		}
+		for _, change := range diffInstances(known[key], current) {
+			f(change.instance, change.event)
+		}
+		if len(current) == 0 {
+			delete(known, key)
+		} else {
+			known[key] = current
+		}
+	}
+
 	c.endpoints.handler.append(func(obj interface{}, event model.Event) error {
-		ep := *obj.(*v1.Endpoints)
-		item, exists := c.serviceByKey(ep.Name, ep.Namespace)
-		var svc *model.Service
-		if exists {
-			svc = convertService(*item)
+		update(obj.(*v1.Endpoints), event == model.EventDelete)
+		return nil
+	})
+
+	// the endpoints drop the address of a deleted pod or the addresses of the
+	// pods on a deleted node, so only additions and updates are re-evaluated
+	c.pods.handler.append(func(obj interface{}, event model.Event) error {
+		if event == model.EventDelete {
+			return nil
+		}
+		if ip := obj.(*v1.Pod).Status.PodIP; ip != "" {
+			for _, ep := range c.endpointsWith(map[string]bool{ip: true}) {
+				update(ep, false)
+			}
+		}
+		return nil
+	})
+	c.nodes.handler.append(func(obj interface{}, event model.Event) error {
+		if event == model.EventDelete {
+			return nil
+		}
+		if addrs := c.pods.addrsOnNode(obj.(*v1.Node).Name); len(addrs) > 0 {
+			for _, ep := range c.endpointsWith(addrs) {
+				update(ep, false)
+			}
 		}
-		// TODO: we're passing an incomplete instance to the handler since endpoints is an aggregate
-		// structure
-		f(&model.ServiceInstance{Service: svc}, event)
 		return nil
 	})
 	return nil
 }
 
+// endpointsWith lists the endpoints with one of the addresses
+func (c *Controller) endpointsWith(addrs map[string]bool) []*v1.Endpoints {
+	var out []*v1.Endpoints
+	for _, item := range c.endpoints.informer.GetStore().List() {
+		ep := item.(*v1.Endpoints)
+	subsets:
+		for _, ss := range ep.Subsets {
+			for _, ea := range convertAddresses(ss) {
+				if addrs[ea.IP] {
+					out = append(out, ep)
+					break subsets
+				}
+			}
+		}
+	}
+	return out
+}
+
 // PodCache is an eventually consistent pod cache
 type PodCache struct {
 	cacheHandler
@@ -629,8 +739,8 @@ func newPodCache(ch cacheHandler) *PodCache {
 	return out
 }
 
-// tagsByIP returns pod tags or nil if pod not found or an error occurred
-func (pc *PodCache) tagsByIP(addr string) (model.Tags, bool) {
+// getPodByIP returns the pod with the address
+func (pc *PodCache) getPodByIP(addr string) (*v1.Pod, bool) {
 	key, exists := pc.keys[addr]
 	if !exists {
 		return nil, false
@@ -639,5 +749,50 @@ func (pc *PodCache) tagsByIP(addr string) (model.Tags, bool) {
 	if !exists || err != nil {
 		return nil, false
 	}
-	return convertTags(item.(*v1.Pod).ObjectMeta), true
+	return item.(*v1.Pod), true
+}
+
+// tagsByIP returns the labels of the pod with the address
+func (pc *PodCache) tagsByIP(addr string) (model.Tags, bool) {
+	pod, exists := pc.getPodByIP(addr)
+	if !exists {
+		return nil, false
+	}
+	return convertTags(pod.ObjectMeta), true
+}
+
+// weightByIP returns the load balancing weight of the pod with the address
+func (pc *PodCache) weightByIP(addr string) int {
+	pod, exists := pc.getPodByIP(addr)
+	if !exists {
+		return 0
+	}
+	return convertWeight(pod.ObjectMeta)
+}
+
+// addrsOnNode returns the addresses of the pods on the node
+func (pc *PodCache) addrsOnNode(node string) map[string]bool {
+	out := make(map[string]bool)
+	for _, item := range pc.informer.GetStore().List() {
+		pod := item.(*v1.Pod)
+		if pod.Spec.NodeName == node && pod.Status.PodIP != "" {
+			out[pod.Status.PodIP] = true
+		}
+	}
+	return out
+}
+
+// localityByIP returns the locality of the node of the pod with the address,
+// or no locality if the node is unknown, e.g. when the controller is not
+// permitted to list the nodes
+func (c *Controller) localityByIP(addr string) model.Locality {
+	pod, exists := c.pods.getPodByIP(addr)
+	if !exists || pod.Spec.NodeName == "" {
+		return model.Locality{}
+	}
+	item, exists, err := c.nodes.informer.GetStore().GetByKey(pod.Spec.NodeName)
+	if !exists || err != nil {
+		return model.Locality{}
+	}
+	return convertLocality(item.(*v1.Node).ObjectMeta)
 }
@@ -39,6 +39,19 @@ const (
 	// ServiceSuffix is the hostname suffix used by a Kubernetes service
 	// TODO: make DNS suffix configurable
 	ServiceSuffix = "svc.cluster.local"
+
+	// RegionLabel is the well-known node label with the region of a node
+	RegionLabel = "failure-domain.beta.kubernetes.io/region"
+
+	// ZoneLabel is the well-known node label with the zone of a node
+	ZoneLabel = "failure-domain.beta.kubernetes.io/zone"
+
+	// SubzoneLabel is the node label with the sub-zone of a node, e.g. a rack
+	SubzoneLabel = "istio.io/subzone"
+
+	// WeightAnnotation is the pod annotation with the load balancing weight of
+	// the pod relative to the other pods of a service, in the range [1, 100]
+	WeightAnnotation = "istio.io/load-balancing-weight"
 )
 
 // serviceGetter is a function that retrieves a service by name and namespace
@@ -73,6 +86,33 @@ func convertTags(obj v1.ObjectMeta) model.Tags {
 	return out
 }
 
+// convertLocality derives the locality of a node from its labels
+func convertLocality(node v1.ObjectMeta) model.Locality {
+	return model.Locality{
+		Region:  node.Labels[RegionLabel],
+		Zone:    node.Labels[ZoneLabel],
+		Subzone: node.Labels[SubzoneLabel],
+	}
+}
+
+// convertWeight reads the load balancing weight of a pod from the annotation.
+// The weight is 0 if the annotation is missing or invalid.
+func convertWeight(pod v1.ObjectMeta) int {
+	value, exists := pod.Annotations[WeightAnnotation]
+	if !exists {
+		return 0
+	}
+	weight, err := strconv.Atoi(value)
+	if err == nil {
+		err = model.ValidateEndpointWeight(weight)
+	}
+	if err != nil {
+		glog.V(2).Infof("Invalid weight annotation %q of pod %s/%s: %v", value, pod.Namespace, pod.Name, err)
+		return 0
+	}
+	return weight
+}
+
 func convertPort(port v1.ServicePort) *model.Port {
 	return &model.Port{
 		Name:     port.Name,
@@ -99,6 +139,25 @@ func convertService(svc v1.Service) *model.Service {
 	}
 }
 
+// endpointAddress is an address of an endpoint subset with its health status
+type endpointAddress struct {
+	v1.EndpointAddress
+	health model.HealthStatus
+}
+
+// convertAddresses lists the ready addresses of an endpoint subset as healthy
+// and the addresses that fail the readiness checks as unhealthy
+func convertAddresses(ss v1.EndpointSubset) []endpointAddress {
+	out := make([]endpointAddress, 0, len(ss.Addresses)+len(ss.NotReadyAddresses))
+	for _, ea := range ss.Addresses {
+		out = append(out, endpointAddress{EndpointAddress: ea, health: model.Healthy})
+	}
+	for _, ea := range ss.NotReadyAddresses {
+		out = append(out, endpointAddress{EndpointAddress: ea, health: model.Unhealthy})
+	}
+	return out
+}
+
 func serviceHostname(serviceName string, namespace string) string {
 	return fmt.Sprintf("%s.%s.%s", serviceName, namespace, ServiceSuffix)
 }
@@ -161,10 +220,26 @@ func modelToKube(km model.KindMap, k *model.Key, v proto.Message) (*Config, erro
 		},
 		Spec: spec,
 	}
+	if kind.Version != "" {
+		out.Metadata.Annotations = map[string]string{SchemaVersionAnnotation: kind.Version}
+	}
 
 	return out, nil
 }
 
+// storedVersion returns the schema version of the stored config if it is older
+// than the schema version of the kind
+func storedVersion(kind model.ProtoSchema, item *Config) string {
+	version := item.Metadata.Annotations[SchemaVersionAnnotation]
+	if version == "" {
+		version = kind.OldestVersion()
+	}
+	if version == kind.Version {
+		return ""
+	}
+	return version
+}
+
 func convertIngress(ingress v1beta1.Ingress, getService serviceGetter) map[model.Key]proto.Message {
 	messages := make(map[model.Key]proto.Message)
 
@@ -18,6 +18,7 @@ import (
 	"testing"
 
 	"istio.io/manager/model"
+	proxyconfig "istio.io/manager/model/proxy/alphav1/config"
 
 	"k8s.io/client-go/pkg/api/v1"
 )
@@ -112,3 +113,73 @@ func TestIsRegularExpression(t *testing.T) {
 		}
 	}
 }
+
+func TestSchemaVersion(t *testing.T) {
+	key := model.Key{Kind: model.RouteRule, Name: "example", Namespace: "default"}
+	config, err := modelToKube(model.IstioConfig, &key, &proxyconfig.RouteRule{Destination: "example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Metadata.Annotations[SchemaVersionAnnotation]; got != model.V1alpha {
+		t.Errorf("got schema version %q, want %q", got, model.V1alpha)
+	}
+
+	schema := model.ProtoSchema{
+		MessageName: model.RouteRuleProto,
+		Version:     "v2",
+		Previous:    []model.SchemaVersion{{Version: model.V1alpha}},
+	}
+	if got := storedVersion(schema, config); got != model.V1alpha {
+		t.Errorf("storedVersion => got %q, want %q", got, model.V1alpha)
+	}
+	config.Metadata.Annotations = nil
+	if got := storedVersion(schema, config); got != model.V1alpha {
+		t.Errorf("storedVersion without annotation => got %q, want %q", got, model.V1alpha)
+	}
+	if got := storedVersion(model.IstioConfig[model.RouteRule], config); got != "" {
+		t.Errorf("storedVersion of the current version => got %q, want empty", got)
+	}
+}
+
+func TestConvertAddresses(t *testing.T) {
+	ss := v1.EndpointSubset{
+		Addresses:         []v1.EndpointAddress{{IP: "10.0.0.1"}},
+		NotReadyAddresses: []v1.EndpointAddress{{IP: "10.0.0.2"}},
+	}
+	got := convertAddresses(ss)
+	if len(got) != 2 ||
+		got[0].IP != "10.0.0.1" || got[0].health != model.Healthy ||
+		got[1].IP != "10.0.0.2" || got[1].health != model.Unhealthy {
+		t.Errorf("convertAddresses() => got %#v", got)
+	}
+}
+
+func TestConvertLocality(t *testing.T) {
+	node := v1.ObjectMeta{Labels: map[string]string{
+		RegionLabel: "us-east1",
+		ZoneLabel:   "us-east1-b",
+	}}
+	want := model.Locality{Region: "us-east1", Zone: "us-east1-b"}
+	if got := convertLocality(node); got != want {
+		t.Errorf("convertLocality() => got %#v, want %#v", got, want)
+	}
+}
+
+func TestConvertWeight(t *testing.T) {
+	cases := []struct {
+		annotations map[string]string
+		weight      int
+	}{
+		{nil, 0},
+		{map[string]string{WeightAnnotation: "20"}, 20},
+		{map[string]string{WeightAnnotation: "100"}, 100},
+		{map[string]string{WeightAnnotation: "0"}, 0},
+		{map[string]string{WeightAnnotation: "101"}, 0},
+		{map[string]string{WeightAnnotation: "heavy"}, 0},
+	}
+	for _, c := range cases {
+		if got := convertWeight(v1.ObjectMeta{Name: "pod", Annotations: c.annotations}); got != c.weight {
+			t.Errorf("convertWeight(%v) => got %d, want %d", c.annotations, got, c.weight)
+		}
+	}
+}
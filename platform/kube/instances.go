@@ -0,0 +1,78 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"istio.io/manager/model"
+)
+
+// instanceEvent is a change of a service instance
+type instanceEvent struct {
+	instance *model.ServiceInstance
+	event    model.Event
+}
+
+// instanceKey identifies a service instance of a service by the endpoint
+// address, the endpoint port and the service port
+func instanceKey(instance *model.ServiceInstance) string {
+	name := ""
+	if instance.Endpoint.ServicePort != nil {
+		name = instance.Endpoint.ServicePort.Name
+	}
+	return fmt.Sprintf("%s:%d:%s", instance.Endpoint.Address, instance.Endpoint.Port, name)
+}
+
+// indexInstances indexes the service instances of a service by instance key
+func indexInstances(instances []*model.ServiceInstance) map[string]*model.ServiceInstance {
+	out := make(map[string]*model.ServiceInstance, len(instances))
+	for _, instance := range instances {
+		out[instanceKey(instance)] = instance
+	}
+	return out
+}
+
+// diffInstances lists the events that change the previous instances of a
+// service into the current instances, in the order of the instance keys
+func diffInstances(previous, current map[string]*model.ServiceInstance) []instanceEvent {
+	keys := make([]string, 0, len(previous)+len(current))
+	for key := range previous {
+		keys = append(keys, key)
+	}
+	for key := range current {
+		if _, exists := previous[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	out := make([]instanceEvent, 0)
+	for _, key := range keys {
+		prev, hadPrev := previous[key]
+		cur, hasCur := current[key]
+		switch {
+		case !hadPrev:
+			out = append(out, instanceEvent{instance: cur, event: model.EventAdd})
+		case !hasCur:
+			out = append(out, instanceEvent{instance: prev, event: model.EventDelete})
+		case !reflect.DeepEqual(prev, cur):
+			out = append(out, instanceEvent{instance: cur, event: model.EventUpdate})
+		}
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	"istio.io/manager/model"
+)
+
+func makeInstance(ip string, tags model.Tags) *model.ServiceInstance {
+	port := &model.Port{Name: "http", Port: 80, Protocol: model.ProtocolHTTP}
+	return &model.ServiceInstance{
+		Endpoint: model.NetworkEndpoint{Address: ip, Port: 8080, ServicePort: port},
+		Service:  &model.Service{Hostname: "hello.default.svc.cluster.local", Ports: model.PortList{port}},
+		Tags:     tags,
+	}
+}
+
+func TestDiffInstances(t *testing.T) {
+	kept := makeInstance("10.0.0.1", model.Tags{"version": "v1"})
+	updated := makeInstance("10.0.0.2", model.Tags{"version": "v1"})
+	deleted := makeInstance("10.0.0.3", model.Tags{"version": "v1"})
+	previous := indexInstances([]*model.ServiceInstance{kept, updated, deleted})
+
+	relabeled := makeInstance("10.0.0.2", model.Tags{"version": "v2"})
+	added := makeInstance("10.0.0.4", model.Tags{"version": "v2"})
+	current := indexInstances([]*model.ServiceInstance{
+		makeInstance("10.0.0.1", model.Tags{"version": "v1"}), relabeled, added})
+
+	want := []instanceEvent{
+		{instance: relabeled, event: model.EventUpdate},
+		{instance: deleted, event: model.EventDelete},
+		{instance: added, event: model.EventAdd},
+	}
+	if got := diffInstances(previous, current); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffInstances() => got %v, want %v", got, want)
+	}
+
+	if got := diffInstances(nil, nil); len(got) != 0 {
+		t.Errorf("diffInstances(nil, nil) => got %v, want none", got)
+	}
+}
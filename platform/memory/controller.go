@@ -0,0 +1,403 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memory provides an in-memory implementation of the configuration
+// registry, the service discovery, and the event controller. It is intended
+// for tests and standalone runs without a platform API server.
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+
+	"istio.io/manager/model"
+)
+
+// Controller is an in-memory registry of configuration objects, services, and
+// service instances. Handlers execute on a single worker in the order they are
+// appended, after the registry state has been updated.
+//
+// Configuration revisions are decimal numbers from a counter that increases
+// on every write.
+type Controller struct {
+	mapping model.KindMap
+	queue   *queue
+
+	// mu protects the registry state and the handlers below
+	mu        sync.RWMutex
+	revision  int64
+	configs   map[model.Key]model.Config
+	services  map[string]*model.Service
+	instances map[string][]*model.ServiceInstance
+
+	configHandlers   map[string][]func(model.Key, proto.Message, model.Event)
+	serviceHandlers  []func(*model.Service, model.Event)
+	instanceHandlers []func(*model.ServiceInstance, model.Event)
+}
+
+// NewController creates an empty in-memory controller for the config kinds
+func NewController(mapping model.KindMap) *Controller {
+	return &Controller{
+		mapping:        mapping,
+		queue:          newQueue(),
+		configs:        make(map[model.Key]model.Config),
+		services:       make(map[string]*model.Service),
+		instances:      make(map[string][]*model.ServiceInstance),
+		configHandlers: make(map[string][]func(model.Key, proto.Message, model.Event)),
+	}
+}
+
+// AppendConfigHandler implements a controller operation
+func (c *Controller) AppendConfigHandler(kind string, f func(model.Key, proto.Message, model.Event)) error {
+	if _, ok := c.mapping[kind]; !ok {
+		return fmt.Errorf("Missing kind %q", kind)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.configHandlers[kind] = append(c.configHandlers[kind], f)
+	return nil
+}
+
+// AppendServiceHandler implements a controller operation
+func (c *Controller) AppendServiceHandler(f func(*model.Service, model.Event)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.serviceHandlers = append(c.serviceHandlers, f)
+	return nil
+}
+
+// AppendInstanceHandler implements a controller operation
+func (c *Controller) AppendInstanceHandler(f func(*model.ServiceInstance, model.Event)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instanceHandlers = append(c.instanceHandlers, f)
+	return nil
+}
+
+// Run the event worker until a signal is received
+func (c *Controller) Run(stop chan struct{}) {
+	go c.queue.run(stop)
+	<-stop
+	glog.V(2).Info("Controller terminated")
+}
+
+func (c *Controller) notifyConfig(key model.Key, msg proto.Message, event model.Event) {
+	c.mu.RLock()
+	handlers := c.configHandlers[key.Kind]
+	c.mu.RUnlock()
+	c.queue.push(func() {
+		glog.V(2).Infof("Event %s: key %v", event, key)
+		for _, f := range handlers {
+			f(key, msg, event)
+		}
+	})
+}
+
+func (c *Controller) notifyService(svc *model.Service, event model.Event) {
+	c.mu.RLock()
+	handlers := c.serviceHandlers
+	c.mu.RUnlock()
+	c.queue.push(func() {
+		glog.V(2).Infof("Event %s: service %s", event, svc.Hostname)
+		for _, f := range handlers {
+			f(svc, event)
+		}
+	})
+}
+
+func (c *Controller) notifyInstance(instance *model.ServiceInstance, event model.Event) {
+	c.mu.RLock()
+	handlers := c.instanceHandlers
+	c.mu.RUnlock()
+	c.queue.push(func() {
+		glog.V(2).Infof("Event %s: instance %s:%d", event,
+			instance.Endpoint.Address, instance.Endpoint.Port)
+		for _, f := range handlers {
+			f(instance, event)
+		}
+	})
+}
+
+// Get implements a registry operation
+func (c *Controller) Get(key model.Key) (proto.Message, bool, string) {
+	if err := c.mapping.ValidateKey(&key); err != nil {
+		glog.Warning(err)
+		return nil, false, ""
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out, exists := c.configs[key]
+	return out.Content, exists, out.Revision
+}
+
+// List implements a registry operation
+func (c *Controller) List(kind, namespace string) ([]model.Config, error) {
+	if _, ok := c.mapping[kind]; !ok {
+		return nil, fmt.Errorf("Missing kind %q", kind)
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]model.Config, 0)
+	for key, config := range c.configs {
+		if key.Kind == kind && (namespace == "" || key.Namespace == namespace) {
+			out = append(out, config)
+		}
+	}
+	return out, nil
+}
+
+// Post implements a registry operation
+func (c *Controller) Post(key model.Key, v proto.Message) (string, error) {
+	if err := c.mapping.ValidateConfig(&key, v); err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	if _, exists := c.configs[key]; exists {
+		c.mu.Unlock()
+		return "", &model.AlreadyExistsError{Key: key}
+	}
+	revision := c.store(key, v)
+	c.mu.Unlock()
+
+	c.notifyConfig(key, v, model.EventAdd)
+	return revision, nil
+}
+
+// Put implements a registry operation
+func (c *Controller) Put(key model.Key, v proto.Message, revision string) (string, error) {
+	if err := c.mapping.ValidateConfig(&key, v); err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	old, exists := c.configs[key]
+	if !exists {
+		c.mu.Unlock()
+		return "", &model.NotFoundError{Key: key}
+	}
+	if old.Revision != revision {
+		c.mu.Unlock()
+		return "", &model.ConflictError{Key: key, Revision: revision}
+	}
+	next := c.store(key, v)
+	c.mu.Unlock()
+
+	c.notifyConfig(key, v, model.EventUpdate)
+	return next, nil
+}
+
+// store saves an object with the next revision, must be called under the write lock
+func (c *Controller) store(key model.Key, v proto.Message) string {
+	c.revision++
+	revision := strconv.FormatInt(c.revision, 10)
+	c.configs[key] = model.Config{Key: key, Revision: revision, Content: v}
+	return revision
+}
+
+// Delete implements a registry operation
+func (c *Controller) Delete(key model.Key) error {
+	if err := c.mapping.ValidateKey(&key); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	old, exists := c.configs[key]
+	delete(c.configs, key)
+	c.mu.Unlock()
+
+	if !exists {
+		return &model.NotFoundError{Key: key}
+	}
+	c.notifyConfig(key, old.Content, model.EventDelete)
+	return nil
+}
+
+// AddService adds or replaces a service declaration
+func (c *Controller) AddService(svc *model.Service) error {
+	if err := svc.Validate(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	_, exists := c.services[svc.Hostname]
+	c.services[svc.Hostname] = svc
+	c.mu.Unlock()
+
+	if exists {
+		c.notifyService(svc, model.EventUpdate)
+	} else {
+		c.notifyService(svc, model.EventAdd)
+	}
+	return nil
+}
+
+// DeleteService removes a service declaration together with its instances
+func (c *Controller) DeleteService(hostname string) error {
+	c.mu.Lock()
+	svc, exists := c.services[hostname]
+	instances := c.instances[hostname]
+	delete(c.services, hostname)
+	delete(c.instances, hostname)
+	c.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("Service %q does not exist", hostname)
+	}
+	for _, instance := range instances {
+		c.notifyInstance(instance, model.EventDelete)
+	}
+	c.notifyService(svc, model.EventDelete)
+	return nil
+}
+
+// AddInstance adds a service instance for a declared service and port.
+// An instance with the same endpoint address and port replaces the existing one.
+func (c *Controller) AddInstance(instance *model.ServiceInstance) error {
+	if instance.Service == nil || instance.Endpoint.ServicePort == nil {
+		return fmt.Errorf("Service instance must reference a service and a service port")
+	}
+	hostname := instance.Service.Hostname
+
+	c.mu.Lock()
+	svc, exists := c.services[hostname]
+	if !exists {
+		c.mu.Unlock()
+		return fmt.Errorf("Service %q does not exist", hostname)
+	}
+	if _, exists = svc.Ports.Get(instance.Endpoint.ServicePort.Name); !exists {
+		c.mu.Unlock()
+		return fmt.Errorf("Service %q does not declare port %q", hostname, instance.Endpoint.ServicePort.Name)
+	}
+
+	event := model.EventAdd
+	list := c.instances[hostname]
+	for i, existing := range list {
+		if sameEndpoint(existing, instance) {
+			list = append(list[:i], list[i+1:]...)
+			event = model.EventUpdate
+			break
+		}
+	}
+	c.instances[hostname] = append(list, instance)
+	c.mu.Unlock()
+
+	c.notifyInstance(instance, event)
+	return nil
+}
+
+// DeleteInstance removes a service instance matching the endpoint address,
+// the endpoint port, and the service port name
+func (c *Controller) DeleteInstance(instance *model.ServiceInstance) error {
+	if instance.Service == nil || instance.Endpoint.ServicePort == nil {
+		return fmt.Errorf("Service instance must reference a service and a service port")
+	}
+	hostname := instance.Service.Hostname
+
+	c.mu.Lock()
+	var old *model.ServiceInstance
+	list := c.instances[hostname]
+	for i, existing := range list {
+		if sameEndpoint(existing, instance) {
+			old = existing
+			c.instances[hostname] = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	if old == nil {
+		return fmt.Errorf("Instance %s:%d of service %q does not exist",
+			instance.Endpoint.Address, instance.Endpoint.Port, hostname)
+	}
+	c.notifyInstance(old, model.EventDelete)
+	return nil
+}
+
+// sameEndpoint checks that two instances of the same service share the network endpoint
+func sameEndpoint(a, b *model.ServiceInstance) bool {
+	return a.Endpoint.Address == b.Endpoint.Address &&
+		a.Endpoint.Port == b.Endpoint.Port &&
+		a.Endpoint.ServicePort.Name == b.Endpoint.ServicePort.Name
+}
+
+// Services implements a service catalog operation
+func (c *Controller) Services() []*model.Service {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]*model.Service, 0, len(c.services))
+	for _, svc := range c.services {
+		out = append(out, svc)
+	}
+	sort.Sort(servicesByHostname(out))
+	return out
+}
+
+// servicesByHostname sorts services by hostname
+type servicesByHostname []*model.Service
+
+func (s servicesByHostname) Len() int {
+	return len(s)
+}
+
+func (s servicesByHostname) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s servicesByHostname) Less(i, j int) bool {
+	return s[i].Hostname < s[j].Hostname
+}
+
+// GetService implements a service catalog operation
+func (c *Controller) GetService(hostname string) (*model.Service, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	svc, exists := c.services[hostname]
+	return svc, exists
+}
+
+// Instances implements a service catalog operation
+func (c *Controller) Instances(hostname string, ports []string, tagsList model.TagsList,
+	health model.HealthFilter) []*model.ServiceInstance {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make(map[string]bool, len(ports))
+	for _, port := range ports {
+		names[port] = true
+	}
+	var out []*model.ServiceInstance
+	for _, instance := range c.instances[hostname] {
+		if names[instance.Endpoint.ServicePort.Name] && tagsList.HasSubsetOf(instance.Tags) &&
+			health.Matches(instance) {
+			out = append(out, instance)
+		}
+	}
+	return out
+}
+
+// HostInstances implements a service catalog operation
+func (c *Controller) HostInstances(addrs map[string]bool) []*model.ServiceInstance {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var out []*model.ServiceInstance
+	for _, list := range c.instances {
+		for _, instance := range list {
+			if addrs[instance.Endpoint.Address] {
+				out = append(out, instance)
+			}
+		}
+	}
+	return out
+}
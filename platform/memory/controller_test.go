@@ -0,0 +1,281 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"istio.io/manager/model"
+	"istio.io/manager/test/mock"
+)
+
+var (
+	hostname = "hello.default.svc.cluster.local"
+	httpPort = &model.Port{Name: "http", Port: 80, Protocol: model.ProtocolHTTP}
+	grpcPort = &model.Port{Name: "grpc", Port: 90, Protocol: model.ProtocolGRPC}
+	service  = &model.Service{
+		Hostname: hostname,
+		Address:  "10.1.0.1",
+		Ports:    model.PortList{httpPort, grpcPort},
+	}
+)
+
+func makeInstance(ip string, port *model.Port, tags model.Tags) *model.ServiceInstance {
+	return &model.ServiceInstance{
+		Endpoint: model.NetworkEndpoint{
+			Address:     ip,
+			Port:        port.Port + 1000,
+			ServicePort: port,
+		},
+		Service: service,
+		Tags:    tags,
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	mock.CheckMapInvariant(NewController(mock.Mapping), t, mock.Namespace, 5)
+}
+
+func TestControllerEvents(t *testing.T) {
+	ctl := NewController(mock.Mapping)
+
+	// test interface implementation
+	var _ model.Controller = ctl
+	var _ model.ServiceDiscovery = ctl
+
+	var mu sync.Mutex
+	events := make([]model.Event, 0)
+	err := ctl.AppendConfigHandler(mock.Kind, func(k model.Key, o proto.Message, ev model.Event) {
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if err = ctl.AppendConfigHandler("missing-kind", nil); err == nil {
+		t.Error("AppendConfigHandler(missing-kind) => got no error")
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go ctl.Run(stop)
+
+	revision, err := ctl.Post(mock.Key, mock.Make(0))
+	if err != nil {
+		t.Error(err)
+	}
+	if _, err = ctl.Put(mock.Key, mock.Make(1), revision); err != nil {
+		t.Error(err)
+	}
+	if err = ctl.Delete(mock.Key); err != nil {
+		t.Error(err)
+	}
+	if err = ctl.Delete(mock.Key); err == nil {
+		t.Error("Delete(missing) => got no error")
+	}
+
+	expected := []model.Event{model.EventAdd, model.EventUpdate, model.EventDelete}
+	eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events) == len(expected)
+	}, t)
+	for i, ev := range expected {
+		if events[i] != ev {
+			t.Errorf("event %d => got %s, want %s", i, events[i], ev)
+		}
+	}
+}
+
+func TestAppendHandlerWhileRunning(t *testing.T) {
+	ctl := NewController(mock.Mapping)
+	stop := make(chan struct{})
+	defer close(stop)
+	go ctl.Run(stop)
+
+	// handlers appended concurrently with the events must not race with the worker
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			if err := ctl.AppendConfigHandler(mock.Kind, func(model.Key, proto.Message, model.Event) {}); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		key := mock.Key
+		key.Name = fmt.Sprintf("%s-%d", key.Name, i)
+		if _, err := ctl.Post(key, mock.Make(i)); err != nil {
+			t.Error(err)
+		}
+	}
+	<-done
+}
+
+func TestApply(t *testing.T) {
+	ctl := NewController(mock.Mapping)
+	keys := []model.Key{
+		{Kind: mock.Kind, Name: "a", Namespace: mock.Namespace},
+		{Kind: mock.Kind, Name: "b", Namespace: mock.Namespace},
+		{Kind: mock.Kind, Name: "c", Namespace: mock.Namespace},
+	}
+	if _, err := ctl.Post(keys[0], mock.Make(0)); err != nil {
+		t.Fatal(err)
+	}
+	_, _, stale := ctl.Get(keys[0])
+
+	// a successful batch updates the existing object and creates the others
+	batch := []model.Config{
+		{Key: keys[0], Content: mock.Make(1)},
+		{Key: keys[1], Content: mock.Make(1)},
+	}
+	if err := model.Apply(ctl, mock.Mapping, batch); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys[:2] {
+		if v, _, _ := ctl.Get(key); !reflect.DeepEqual(v, mock.Make(1)) {
+			t.Errorf("Get(%v) => got %v, want %v", key, v, mock.Make(1))
+		}
+	}
+
+	// an invalid batch is rejected before any write
+	invalid := []model.Config{
+		{Key: keys[0], Content: mock.Make(2)},
+		{Key: model.Key{Kind: mock.Kind, Name: "Invalid", Namespace: mock.Namespace}, Content: mock.Make(2)},
+		{Key: keys[0], Content: mock.Make(2)},
+	}
+	if err := model.Apply(ctl, mock.Mapping, invalid); err == nil {
+		t.Error("Apply(invalid) => got no error")
+	}
+	if v, _, _ := ctl.Get(keys[0]); !reflect.DeepEqual(v, mock.Make(1)) {
+		t.Errorf("Get(%v) => got %v after an invalid batch", keys[0], v)
+	}
+
+	// a failed write restores the objects written by the batch
+	failing := []model.Config{
+		{Key: keys[1], Content: mock.Make(3)},
+		{Key: keys[2], Content: mock.Make(3)},
+		{Key: keys[0], Content: mock.Make(3), Revision: stale},
+	}
+	if err := model.Apply(ctl, mock.Mapping, failing); err == nil {
+		t.Error("Apply(stale revision) => got no error")
+	}
+	if v, _, _ := ctl.Get(keys[1]); !reflect.DeepEqual(v, mock.Make(1)) {
+		t.Errorf("Get(%v) => got %v, want the restored %v", keys[1], v, mock.Make(1))
+	}
+	if _, exists, _ := ctl.Get(keys[2]); exists {
+		t.Errorf("Get(%v) => got an object created by the failed batch", keys[2])
+	}
+}
+
+func TestServiceDiscovery(t *testing.T) {
+	ctl := NewController(model.IstioConfig)
+	if err := ctl.AddInstance(makeInstance("10.0.0.1", httpPort, nil)); err == nil {
+		t.Error("AddInstance(undeclared service) => got no error")
+	}
+	if err := ctl.AddService(service); err != nil {
+		t.Fatal(err)
+	}
+
+	v1 := model.Tags{"version": "v1"}
+	v2 := model.Tags{"version": "v2"}
+	for _, instance := range []*model.ServiceInstance{
+		makeInstance("10.0.0.1", httpPort, v1),
+		makeInstance("10.0.0.1", grpcPort, v1),
+		makeInstance("10.0.0.2", httpPort, v2),
+	} {
+		if err := ctl.AddInstance(instance); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if svcs := ctl.Services(); len(svcs) != 1 || svcs[0] != service {
+		t.Errorf("Services() => got %v", svcs)
+	}
+	if svc, exists := ctl.GetService(hostname); !exists || svc != service {
+		t.Errorf("GetService(%q) => got %v, %t", hostname, svc, exists)
+	}
+
+	cases := []struct {
+		ports []string
+		tags  model.TagsList
+		count int
+	}{
+		{[]string{"http"}, nil, 2},
+		{[]string{"http", "grpc"}, nil, 3},
+		{[]string{"http"}, model.TagsList{v1}, 1},
+		{[]string{"http", "grpc"}, model.TagsList{v2}, 1},
+		{[]string{"http"}, model.TagsList{{"version": "v3"}}, 0},
+		{[]string{"missing"}, nil, 0},
+	}
+	for _, c := range cases {
+		if out := ctl.Instances(hostname, c.ports, c.tags, model.AnyHealth); len(out) != c.count {
+			t.Errorf("Instances(%v, %v) => got %d, want %d", c.ports, c.tags, len(out), c.count)
+		}
+	}
+
+	if out := ctl.HostInstances(map[string]bool{"10.0.0.1": true}); len(out) != 2 {
+		t.Errorf("HostInstances() => got %d, want 2", len(out))
+	}
+
+	unhealthy := makeInstance("10.0.0.3", httpPort, nil)
+	unhealthy.Health = model.Unhealthy
+	if err := ctl.AddInstance(unhealthy); err != nil {
+		t.Error(err)
+	}
+	if out := ctl.Instances(hostname, []string{"http"}, nil, model.AnyHealth); len(out) != 3 {
+		t.Errorf("Instances(AnyHealth) => got %d, want 3", len(out))
+	}
+	if out := ctl.Instances(hostname, []string{"http"}, nil, model.HealthyOnly); len(out) != 2 {
+		t.Errorf("Instances(HealthyOnly) => got %d, want 2", len(out))
+	}
+	if err := ctl.DeleteInstance(unhealthy); err != nil {
+		t.Error(err)
+	}
+
+	if err := ctl.DeleteInstance(makeInstance("10.0.0.2", httpPort, nil)); err != nil {
+		t.Error(err)
+	}
+	if out := ctl.Instances(hostname, []string{"http"}, nil, model.AnyHealth); len(out) != 1 {
+		t.Errorf("Instances() after delete => got %d, want 1", len(out))
+	}
+
+	if err := ctl.DeleteService(hostname); err != nil {
+		t.Error(err)
+	}
+	if out := ctl.HostInstances(map[string]bool{"10.0.0.1": true}); len(out) != 0 {
+		t.Errorf("HostInstances() after service delete => got %d, want 0", len(out))
+	}
+}
+
+func eventually(f func() bool, t *testing.T) {
+	interval := 8 * time.Millisecond
+	for i := 0; i < 10; i++ {
+		if f() {
+			return
+		}
+		time.Sleep(interval)
+		interval = 2 * interval
+	}
+	t.Fatal("Failed to satisfy function")
+}
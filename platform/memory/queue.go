@@ -0,0 +1,73 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"sync"
+)
+
+// task is a unit of work executed by the queue worker
+type task func()
+
+// queue is an unbounded FIFO of tasks processed by a single worker
+type queue struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	tasks   []task
+	closing bool
+}
+
+func newQueue() *queue {
+	q := &queue{tasks: make([]task, 0)}
+	q.cond = sync.NewCond(&q.lock)
+	return q
+}
+
+// push appends a task to the queue; tasks pushed after closing are dropped
+func (q *queue) push(t task) {
+	q.lock.Lock()
+	if !q.closing {
+		q.tasks = append(q.tasks, t)
+		q.cond.Signal()
+	}
+	q.lock.Unlock()
+}
+
+// run processes tasks in order until a signal on the channel
+func (q *queue) run(stop <-chan struct{}) {
+	go func() {
+		<-stop
+		q.lock.Lock()
+		q.closing = true
+		q.cond.Signal()
+		q.lock.Unlock()
+	}()
+
+	for {
+		q.lock.Lock()
+		for !q.closing && len(q.tasks) == 0 {
+			q.cond.Wait()
+		}
+		if q.closing {
+			q.lock.Unlock()
+			return
+		}
+		var item task
+		item, q.tasks = q.tasks[0], q.tasks[1:]
+		q.lock.Unlock()
+
+		item()
+	}
+}
@@ -51,8 +51,9 @@ type instance struct {
 }
 
 const (
-	// EnvoyFileTemplate is a template for the root config JSON
-	EnvoyFileTemplate = "%s/envoy-rev%d.json"
+	// EnvoyFileTemplate is a template for the root config JSON, named by the
+	// restart epoch and the configuration version
+	EnvoyFileTemplate = "%s/envoy-rev%d-v%d.json"
 
 	// DrainTimeSeconds is the duration of the grace period to drain connections
 	// from an older proxy instance
@@ -79,8 +80,8 @@ func NewAgent(binary string, configRoot string, nodeName string) Agent {
 	}
 }
 
-func configFile(config string, epoch int) string {
-	return fmt.Sprintf(EnvoyFileTemplate, config, epoch)
+func configFile(config string, epoch int, version uint64) string {
+	return fmt.Sprintf(EnvoyFileTemplate, config, epoch, version)
 }
 
 // Reload Envoy with a hot restart. Envoy hot restarts are performed by launching a new Envoy process with an
@@ -104,7 +105,7 @@ func (s *agent) Reload(config *Config) error {
 	epoch++
 
 	// Write config file
-	fname := configFile(s.configRoot, epoch)
+	fname := configFile(s.configRoot, epoch, config.Version)
 	if err := config.WriteFile(fname); err != nil {
 		return err
 	}
@@ -118,6 +119,10 @@ func (s *agent) Reload(config *Config) error {
 		"--service-node", s.serviceNode,
 	}
 
+	if config.ServiceZone != "" {
+		args = append(args, "--service-zone", config.ServiceZone)
+	}
+
 	if glog.V(4) {
 		args = append(args, "-l", "trace")
 	} else if glog.V(3) {
@@ -130,7 +135,7 @@ func (s *agent) Reload(config *Config) error {
 		}
 	}
 
-	glog.V(2).Infof("Envoy starting: %v", args)
+	glog.V(2).Infof("Envoy starting with configuration version %d: %v", config.Version, args)
 
 	/* #nosec */
 	cmd := exec.Command(s.binary, args...)
@@ -176,7 +181,8 @@ func (s *agent) waitForExit(cmd *exec.Cmd) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	epoch := s.cmdMap[cmd].epoch
+	running := s.cmdMap[cmd]
+	epoch := running.epoch
 	if err != nil {
 		glog.V(2).Infof("Envoy epoch %d terminated: %v", epoch, err)
 	} else {
@@ -184,7 +190,7 @@ func (s *agent) waitForExit(cmd *exec.Cmd) {
 	}
 
 	// delete config file
-	path := configFile(s.configRoot, epoch)
+	path := configFile(s.configRoot, epoch, running.config.Version)
 	if err := os.Remove(path); err != nil {
 		glog.Warningf("Failed to delete config file %s, %v", path, err)
 	}
@@ -39,8 +39,9 @@ import (
 // - lists in the config must be de-duplicated and ordered in a canonical way
 
 // TODO: missing features in the config generation:
-// - TCP routing
-// - HTTPS protocol for inbound and outbound configuration using TCP routing or SNI
+// - HTTPS routing by SNI: the TCP proxy filter selects the routes only by the
+//   original destination, so HTTPS traffic is passed through by address. HTTPS
+//   ports of external services and of services without an address are skipped.
 // - HTTP pod port collision creates duplicate virtual host entries
 // - (bug) two service ports with the same target port create two virtual hosts with same domains
 //   (not allowed by envoy). FIXME - need to detect and eliminate such ports in validation
@@ -94,7 +95,7 @@ func Generate(instances []*model.ServiceInstance, services []*model.Service,
 	})
 
 	// add SDS cluster
-	return &Config{
+	out := &Config{
 		Listeners: listeners,
 		Admin: Admin{
 			AccessLogPath: DefaultAccessLog,
@@ -108,13 +109,16 @@ func Generate(instances []*model.ServiceInstance, services []*model.Service,
 			},
 		},
 	}
+
+	insertZoneAwareRouting(instances, config, out)
+	return out
 }
 
 // build combines the outbound and inbound routes prioritizing the latter
 func build(instances []*model.ServiceInstance, services []*model.Service,
 	config *model.IstioRegistry, mesh *MeshConfig) ([]*Listener, Clusters) {
-	outbound := buildOutboundFilters(instances, services, config, mesh)
-	inbound := buildInboundFilters(instances)
+	outbound, outboundTCP := buildOutboundFilters(instances, services, config, mesh)
+	inbound, inboundTCP := buildInboundFilters(instances)
 
 	// merge the two sets of route configs
 	routeConfigs := make(RouteConfigs)
@@ -133,11 +137,43 @@ func build(instances []*model.ServiceInstance, services []*model.Service,
 		}
 	}
 
+	// merge the two sets of TCP route configs, with the inbound routes first
+	tcpConfigs := make(TCPRouteConfigs)
+	for port, tcpConfig := range inboundTCP {
+		tcpConfigs[port] = tcpConfig
+	}
+
+	for port, outgoing := range outboundTCP {
+		if incoming, ok := tcpConfigs[port]; ok {
+			tcpConfigs[port] = incoming.merge(outgoing)
+		} else {
+			tcpConfigs[port] = outgoing
+		}
+	}
+
+	// a listener serves either HTTP or TCP traffic on a port: the inbound
+	// traffic takes priority, and then the HTTP traffic
+	for port := range tcpConfigs {
+		if _, ok := routeConfigs[port]; !ok {
+			continue
+		}
+		_, http := inbound[port]
+		_, tcp := inboundTCP[port]
+		if tcp && !http {
+			glog.Warningf("Outbound HTTP routes on port %d conflict with inbound TCP routes", port)
+			delete(routeConfigs, port)
+		} else {
+			glog.Warningf("TCP routes on port %d conflict with HTTP routes", port)
+			delete(tcpConfigs, port)
+		}
+	}
+
 	// canonicalize listeners and collect clusters
 	clusters := make(Clusters, 0)
 	listeners := make([]*Listener, 0)
 	for port, routeConfig := range routeConfigs {
 		sort.Sort(HostsByName(routeConfig.VirtualHosts))
+		insertRoutePolicies(config, routeConfig)
 		clusters = append(clusters, routeConfig.clusters()...)
 
 		filters := buildFaultFilters(config, routeConfig)
@@ -153,7 +189,7 @@ func build(instances []*model.ServiceInstance, services []*model.Service,
 			Filters: []*NetworkFilter{{
 				Type: "read",
 				Name: HTTPConnectionManager,
-				Config: NetworkFilterConfig{
+				Config: &NetworkFilterConfig{
 					CodecType:  "auto",
 					StatPrefix: "http",
 					AccessLog: []AccessLog{{
@@ -166,9 +202,29 @@ func build(instances []*model.ServiceInstance, services []*model.Service,
 		}
 		listeners = append(listeners, listener)
 	}
+
+	for port, tcpConfig := range tcpConfigs {
+		sort.Stable(TCPRoutesByCondition(tcpConfig.Routes))
+		tcpConfig.Routes = uniqueTCPRoutes(port, tcpConfig.Routes)
+		clusters = append(clusters, tcpConfig.clusters()...)
+
+		listener := &Listener{
+			Port: port,
+			Filters: []*NetworkFilter{{
+				Type: "read",
+				Name: TCPProxyFilter,
+				Config: &TCPProxyFilterConfig{
+					StatPrefix:  "tcp",
+					RouteConfig: tcpConfig,
+				},
+			}},
+		}
+		listeners = append(listeners, listener)
+	}
 	sort.Sort(ListenersByPort(listeners))
 
 	clusters = clusters.Normalize()
+	insertExternalServices(services, clusters)
 	for _, cluster := range clusters {
 		insertDestinationPolicy(config, cluster)
 	}
@@ -176,13 +232,18 @@ func build(instances []*model.ServiceInstance, services []*model.Service,
 	return listeners, clusters
 }
 
-// buildOutboundFilters creates route configs indexed by ports for the traffic outbound
-// from the proxy instance
+// buildOutboundFilters creates HTTP and TCP route configs indexed by ports for the
+// traffic outbound from the proxy instance
 func buildOutboundFilters(instances []*model.ServiceInstance, services []*model.Service,
-	config *model.IstioRegistry, mesh *MeshConfig) RouteConfigs {
+	config *model.IstioRegistry, mesh *MeshConfig) (RouteConfigs, TCPRouteConfigs) {
 	// used for shortcut domain names for outbound hostnames
 	suffix := sharedInstanceHost(instances)
 	httpConfigs := make(RouteConfigs)
+	tcpConfigs := make(TCPRouteConfigs)
+
+	// the order of the services decides between the TCP routes of rules with the same subnets
+	services = append([]*model.Service(nil), services...)
+	sort.Sort(ServicesByName(services))
 
 	// outbound connections/requests are redirected to service ports; we create a
 	// map for each service port to define filters
@@ -194,21 +255,40 @@ func buildOutboundFilters(instances []*model.ServiceInstance, services []*model.
 				host := buildVirtualHost(service, port, suffix, routes)
 				http := httpConfigs.EnsurePort(port.Port)
 				http.VirtualHosts = append(http.VirtualHosts, host)
+			case model.ProtocolTCP, model.ProtocolHTTPS:
+				// external services are not distinguished by address
+				if service.External != nil {
+					glog.Warningf("Unsupported %v port %d of external service %s",
+						port.Protocol, port.Port, service.Hostname)
+					continue
+				}
+				// HTTPS traffic is passed through without terminating TLS, so the
+				// services without an address cannot be told apart on a shared port
+				if port.Protocol == model.ProtocolHTTPS && service.Address == "" {
+					glog.Warningf("Unsupported HTTPS port %d of service %s without an address",
+						port.Port, service.Hostname)
+					continue
+				}
+				if routes := buildTCPRoutes(service, port, config); len(routes) > 0 {
+					tcp := tcpConfigs.EnsurePort(port.Port)
+					tcp.Routes = append(tcp.Routes, routes...)
+				}
 			default:
 				glog.Warningf("Unsupported outbound protocol %v for port %d", port.Protocol, port.Port)
 			}
 		}
 	}
 
-	return httpConfigs
+	return httpConfigs, tcpConfigs
 }
 
-// buildInboundFilters creates route configs indexed by ports for the traffic inbound
-// to co-located service instances
-func buildInboundFilters(instances []*model.ServiceInstance) RouteConfigs {
+// buildInboundFilters creates HTTP and TCP route configs indexed by ports for the
+// traffic inbound to co-located service instances
+func buildInboundFilters(instances []*model.ServiceInstance) (RouteConfigs, TCPRouteConfigs) {
 	// used for shortcut domain names for hostnames
 	suffix := sharedInstanceHost(instances)
 	httpConfigs := make(RouteConfigs)
+	tcpConfigs := make(TCPRouteConfigs)
 
 	// inbound connections/requests are redirected to the endpoint port but appear to be sent
 	// to the service port
@@ -228,10 +308,15 @@ func buildInboundFilters(instances []*model.ServiceInstance) RouteConfigs {
 
 			http := httpConfigs.EnsurePort(instance.Endpoint.Port)
 			http.VirtualHosts = append(http.VirtualHosts, host)
+		case model.ProtocolTCP, model.ProtocolHTTPS:
+			cluster := buildInboundCluster(instance.Endpoint.Port, port.Protocol)
+			route := buildTCPRoute(cluster, []string{instance.Endpoint.Address}, instance.Endpoint.Port)
+			tcp := tcpConfigs.EnsurePort(instance.Endpoint.Port)
+			tcp.Routes = append(tcp.Routes, route)
 		default:
 			glog.Warningf("Unsupported inbound protocol %v for port %d", port.Protocol, port)
 		}
 	}
 
-	return httpConfigs
+	return httpConfigs, tcpConfigs
 }
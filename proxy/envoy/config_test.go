@@ -20,6 +20,9 @@ import (
 )
 
 func TestRoutesByPath(t *testing.T) {
+	regex := func(value string) *Route {
+		return &Route{Prefix: "/", Headers: Headers{{Name: HeaderPath, Value: value, Regex: true}}}
+	}
 	cases := []struct {
 		in       []*Route
 		expected []*Route
@@ -48,6 +51,34 @@ func TestRoutesByPath(t *testing.T) {
 				{Prefix: "/api"},
 			},
 		},
+
+		// Case 4: Regex before prefix
+		{
+			in: []*Route{
+				{Prefix: "/"},
+				{Prefix: "/api"},
+				regex("/api/v[0-9]+/.*"),
+			},
+			expected: []*Route{
+				regex("/api/v[0-9]+/.*"),
+				{Prefix: "/api"},
+				{Prefix: "/"},
+			},
+		},
+
+		// Case 5: Path before regex, regexes sorted lexicographically
+		{
+			in: []*Route{
+				regex("/b.*"),
+				regex("/a.*"),
+				{Path: "/b"},
+			},
+			expected: []*Route{
+				{Path: "/b"},
+				regex("/a.*"),
+				regex("/b.*"),
+			},
+		},
 	}
 
 	// Function to determine if two *Route slices
@@ -57,6 +88,11 @@ func TestRoutesByPath(t *testing.T) {
 			if r.Path != r2[i].Path || r.Prefix != r2[i].Prefix {
 				return false
 			}
+			regex1, _ := r.pathRegex()
+			regex2, _ := r2[i].pathRegex()
+			if regex1 != regex2 {
+				return false
+			}
 		}
 		return true
 	}
@@ -0,0 +1,97 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// debouncer coalesces bursts of events into a single call of a function.
+// The function is called once no event has arrived for the quiet period, or
+// once the maximum delay has passed since the first event of the burst,
+// whichever comes first. Events that arrive while the function runs cause
+// another call, so that the last event is always followed by a call.
+type debouncer struct {
+	f        func()
+	quiet    time.Duration
+	maxDelay time.Duration
+	events   chan struct{}
+}
+
+// newDebouncer creates a debouncer for a function
+func newDebouncer(f func(), quiet, maxDelay time.Duration) *debouncer {
+	return &debouncer{
+		f:        f,
+		quiet:    quiet,
+		maxDelay: maxDelay,
+		events:   make(chan struct{}, 1),
+	}
+}
+
+// trigger records an event without blocking
+func (d *debouncer) trigger() {
+	select {
+	case d.events <- struct{}{}:
+	default:
+		// an event is already pending
+	}
+}
+
+// run calls the function for the events until a signal is received
+func (d *debouncer) run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-d.events:
+		}
+
+		count, ok := d.coalesce(stop)
+		if !ok {
+			return
+		}
+		glog.V(2).Infof("Coalesced %d events", count)
+		d.f()
+	}
+}
+
+// coalesce waits until the end of a burst of events following the first event.
+// It returns the number of events, or false if a signal is received.
+func (d *debouncer) coalesce(stop <-chan struct{}) (int, bool) {
+	deadline := time.NewTimer(d.maxDelay)
+	defer deadline.Stop()
+	quiet := time.NewTimer(d.quiet)
+	defer quiet.Stop()
+
+	count := 1
+	for {
+		select {
+		case <-stop:
+			return count, false
+		case <-d.events:
+			count++
+			if !quiet.Stop() {
+				<-quiet.C
+			}
+			quiet.Reset(d.quiet)
+		case <-quiet.C:
+			return count, true
+		case <-deadline.C:
+			return count, true
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDebouncerBurst(t *testing.T) {
+	calls := make(chan time.Time, 10)
+	d := newDebouncer(func() { calls <- time.Now() }, 50*time.Millisecond, time.Minute)
+	stop := make(chan struct{})
+	defer close(stop)
+	go d.run(stop)
+
+	for i := 0; i < 5; i++ {
+		d.trigger()
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case <-calls:
+	case <-time.After(time.Second):
+		t.Fatal("no call after a burst of events")
+	}
+	select {
+	case <-calls:
+		t.Error("more than one call for a burst of events")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDebouncerMaxDelay(t *testing.T) {
+	calls := make(chan time.Time, 10)
+	d := newDebouncer(func() { calls <- time.Now() }, 50*time.Millisecond, 100*time.Millisecond)
+	stop := make(chan struct{})
+	defer close(stop)
+	go d.run(stop)
+
+	// events never quiet down
+	start := time.Now()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				d.trigger()
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}()
+
+	select {
+	case at := <-calls:
+		if delay := at.Sub(start); delay > time.Second {
+			t.Errorf("call after %v, want about the maximum delay", delay)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no call within the maximum delay")
+	}
+}
+
+func TestDebouncerEventDuringCall(t *testing.T) {
+	calls := make(chan struct{}, 10)
+	running := make(chan struct{})
+	release := make(chan struct{})
+	first := true
+	d := newDebouncer(func() {
+		if first {
+			first = false
+			close(running)
+			<-release
+		}
+		calls <- struct{}{}
+	}, 10*time.Millisecond, time.Second)
+	stop := make(chan struct{})
+	defer close(stop)
+	go d.run(stop)
+
+	d.trigger()
+	<-running
+	// the last event arrives while the function runs
+	d.trigger()
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-calls:
+		case <-time.After(time.Second):
+			t.Fatalf("got %d calls, want 2", i)
+		}
+	}
+}
@@ -35,8 +35,15 @@ type hosts struct {
 }
 
 type host struct {
-	Address string `json:"ip_address"`
-	Port    int    `json:"port"`
+	Address string    `json:"ip_address"`
+	Port    int       `json:"port"`
+	Tags    *hostTags `json:"tags,omitempty"`
+}
+
+// hostTags are the optional attributes of a host
+type hostTags struct {
+	// AZ is the zone of the host, used by zone aware routing
+	AZ string `json:"az,omitempty"`
 
 	// Weight is an integer in the range [1, 100] or empty
 	Weight int `json:"load_balancing_weight,omitempty"`
@@ -88,13 +95,25 @@ func (ds *DiscoveryService) Run() {
 // ListEndpoints responds to SDS requests
 func (ds *DiscoveryService) ListEndpoints(request *restful.Request, response *restful.Response) {
 	key := request.PathParameter("service-key")
-	hostname, ports, tags := model.ParseServiceKey(key)
+	hostname, ports, tags, err := model.ParseServiceKey(key)
+	if err != nil {
+		glog.V(2).Infof("ListEndpoints(%q) => %v", key, err)
+		if err = response.WriteErrorString(http.StatusBadRequest, err.Error()); err != nil {
+			glog.Warning(err)
+		}
+		return
+	}
+	// unhealthy instances receive no traffic
 	out := make([]host, 0)
-	for _, ep := range ds.services.Instances(hostname, ports.GetNames(), tags) {
-		out = append(out, host{
+	for _, ep := range ds.services.Instances(hostname, ports.GetNames(), tags, model.HealthyOnly) {
+		h := host{
 			Address: ep.Endpoint.Address,
 			Port:    ep.Endpoint.Port,
-		})
+		}
+		if ep.Endpoint.Locality.Zone != "" || ep.Endpoint.Weight > 0 {
+			h.Tags = &hostTags{AZ: ep.Endpoint.Locality.Zone, Weight: ep.Endpoint.Weight}
+		}
+		out = append(out, h)
 	}
 	if err := response.WriteEntity(hosts{out}); err != nil {
 		glog.Warning(err)
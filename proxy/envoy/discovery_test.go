@@ -0,0 +1,131 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	restful "github.com/emicklei/go-restful"
+
+	"istio.io/manager/model"
+	"istio.io/manager/platform/memory"
+)
+
+var (
+	testHostname = "hello.default.svc.cluster.local"
+	testZone     = "us-east1-b"
+	testHTTPPort = &model.Port{Name: "http", Port: 80, Protocol: model.ProtocolHTTP}
+	testService  = &model.Service{
+		Hostname: testHostname,
+		Address:  "10.1.0.1",
+		Ports:    model.PortList{testHTTPPort},
+	}
+)
+
+func makeTestInstance(ip string, tags model.Tags) *model.ServiceInstance {
+	return &model.ServiceInstance{
+		Endpoint: model.NetworkEndpoint{
+			Address:     ip,
+			Port:        8080,
+			ServicePort: testHTTPPort,
+		},
+		Service: testService,
+		Tags:    tags,
+	}
+}
+
+func makeTestController(t *testing.T) *memory.Controller {
+	ctl := memory.NewController(model.IstioConfig)
+	v1 := makeTestInstance("10.0.0.1", model.Tags{"version": "v1"})
+	v1.Endpoint.Locality = model.Locality{Region: "us-east1", Zone: testZone}
+	v2 := makeTestInstance("10.0.0.2", model.Tags{"version": "v2"})
+	v2.Endpoint.Weight = 20
+	unhealthy := makeTestInstance("10.0.0.3", model.Tags{"version": "v3"})
+	unhealthy.Health = model.Unhealthy
+	if err := ctl.AddService(testService); err != nil {
+		t.Fatal(err)
+	}
+	for _, instance := range []*model.ServiceInstance{
+		v1,
+		v2,
+		unhealthy,
+	} {
+		if err := ctl.AddInstance(instance); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ctl
+}
+
+func TestListEndpoints(t *testing.T) {
+	ds := NewDiscoveryService(makeTestController(t), 0)
+	container := restful.NewContainer()
+	ds.Register(container)
+
+	// hosts is -1 for malformed keys
+	cases := []struct {
+		key   string
+		hosts int
+	}{
+		{testService.Key(testHTTPPort, nil), 2},
+		{testService.Key(testHTTPPort, model.Tags{"version": "v1"}), 1},
+		{testService.Key(testHTTPPort, model.Tags{"version": "v3"}), 0}, // unhealthy
+		{testService.Key(testHTTPPort, model.Tags{"version": "notin (v1)"}), 1},
+		{testService.Key(testHTTPPort, model.Tags{"version": "in (v1, v2)"}), 2},
+		{testService.Key(testHTTPPort, model.Tags{"version": model.SelectorExists}), 2},
+		{testService.Key(testHTTPPort, model.Tags{"version": model.SelectorNotExists}), 0},
+		{"missing.default.svc.cluster.local:http", 0},
+		{testHostname + ":http:version=v1", 1},
+		{"v1@" + testHostname + ":http:version", -1},
+		{"v2@" + testHostname + ":http:", -1},
+	}
+	for _, c := range cases {
+		request, err := http.NewRequest("GET", "/v1/registration/"+c.key, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := httptest.NewRecorder()
+		container.ServeHTTP(response, request)
+
+		if c.hosts < 0 {
+			if response.Code != http.StatusBadRequest {
+				t.Errorf("ListEndpoints(%q) => status %d, want %d", c.key, response.Code, http.StatusBadRequest)
+			}
+			continue
+		}
+		if response.Code != http.StatusOK {
+			t.Errorf("ListEndpoints(%q) => status %d", c.key, response.Code)
+			continue
+		}
+		out := hosts{}
+		if err = json.Unmarshal(response.Body.Bytes(), &out); err != nil {
+			t.Error(err)
+		}
+		if len(out.Hosts) != c.hosts {
+			t.Errorf("ListEndpoints(%q) => got %d hosts, want %d", c.key, len(out.Hosts), c.hosts)
+		}
+		for _, h := range out.Hosts {
+			if h.Address == "10.0.0.1" && (h.Tags == nil || h.Tags.AZ != testZone) {
+				t.Errorf("ListEndpoints(%q) => got host %#v, want zone %q", c.key, h, testZone)
+			}
+			if h.Address == "10.0.0.2" && (h.Tags == nil || h.Tags.Weight != 20) {
+				t.Errorf("ListEndpoints(%q) => got host %#v, want weight 20", c.key, h)
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+
+	"istio.io/manager/model"
+)
+
+type egressWatcher struct {
+	agent       Agent
+	snapshotter *model.Snapshotter
+	mesh        *MeshConfig
+	debouncer   *debouncer
+}
+
+// NewEgressWatcher creates a new egress watcher instance with an agent
+func NewEgressWatcher(discovery model.ServiceDiscovery, ctl model.Controller,
+	registry *model.IstioRegistry, mesh *MeshConfig, identity *ProxyNode) (Watcher, error) {
+
+	out := &egressWatcher{
+		agent:       NewAgent(mesh.BinaryPath, mesh.ConfigPath, identity.Name),
+		snapshotter: model.NewSnapshotter(discovery, registry, model.IstioConfig),
+		mesh:        mesh,
+	}
+	out.debouncer = newDebouncer(out.reload, mesh.ReloadQuietPeriod, mesh.ReloadMaxDelay)
+
+	// only the external services are exposed by the egress proxy
+	if err := ctl.AppendServiceHandler(func(svc *model.Service, _ model.Event) {
+		if svc.External != nil {
+			out.debouncer.trigger()
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	handler := func(model.Key, proto.Message, model.Event) { out.debouncer.trigger() }
+
+	if err := ctl.AppendConfigHandler(model.RouteRule, handler); err != nil {
+		return nil, err
+	}
+
+	if err := ctl.AppendConfigHandler(model.Destination, handler); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// Run implements a watcher operation
+func (w *egressWatcher) Run(stop chan struct{}) {
+	w.debouncer.run(stop)
+}
+
+func (w *egressWatcher) reload() {
+	snapshot := w.snapshotter.Snapshot(nil)
+	config := GenerateEgress(snapshot.Services(), &model.IstioRegistry{ConfigRegistry: snapshot}, w.mesh)
+	config.Version = snapshot.Version
+
+	if sameConfig(config, w.agent.ActiveConfig()) {
+		glog.V(2).Infof("Configuration version %d is identical, skipping reload", config.Version)
+		return
+	}
+
+	// TODO: add retry logic
+	if err := w.agent.Reload(config); err != nil {
+		glog.Warningf("Envoy reload error: %v", err)
+		return
+	}
+
+	// Add a short delay to de-risk a potential race condition in envoy hot reload code.
+	// The condition occurs when the active Envoy instance terminates in the middle of
+	// the Reload() function.
+	time.Sleep(256 * time.Millisecond)
+}
+
+// GenerateEgress generates the Envoy egress proxy configuration. The proxy
+// listens on the egress port and forwards requests to the external services by
+// the host header, resolving the external hostnames with DNS.
+func GenerateEgress(services []*model.Service, config *model.IstioRegistry, mesh *MeshConfig) *Config {
+	vhosts := make([]*VirtualHost, 0)
+	for _, service := range services {
+		if service.External == nil {
+			continue
+		}
+		for _, port := range service.Ports {
+			switch port.Protocol {
+			case model.ProtocolHTTP, model.ProtocolHTTP2, model.ProtocolGRPC:
+				// route rules may split the external traffic with the services in the mesh
+				routes := buildHTTPRoutes(service.Hostname, port, config)
+				vhosts = append(vhosts, buildVirtualHost(service, port, nil, routes))
+			default:
+				glog.Warningf("Unsupported egress protocol %v for port %d", port.Protocol, port.Port)
+			}
+		}
+	}
+	sort.Sort(HostsByName(vhosts))
+
+	rConfig := &RouteConfig{VirtualHosts: vhosts}
+	insertRoutePolicies(config, rConfig)
+
+	filters := buildFaultFilters(config, rConfig)
+	filters = append(filters, Filter{
+		Type:   "decoder",
+		Name:   "router",
+		Config: FilterRouterConfig{},
+	})
+
+	listeners := []*Listener{{
+		Port:       EgressPort,
+		BindToPort: true,
+		Filters: []*NetworkFilter{{
+			Type: "read",
+			Name: HTTPConnectionManager,
+			Config: &NetworkFilterConfig{
+				CodecType:   "auto",
+				StatPrefix:  "http",
+				AccessLog:   []AccessLog{{Path: DefaultAccessLog}},
+				RouteConfig: rConfig,
+				Filters:     filters,
+			},
+		}},
+	}}
+
+	if mesh.MixerAddress != "" {
+		insertMixerFilter(listeners, mesh.MixerAddress)
+	}
+
+	clusters := Clusters(rConfig.clusters()).Normalize()
+	insertExternalServices(services, clusters)
+	for _, cluster := range clusters {
+		insertDestinationPolicy(config, cluster)
+	}
+
+	return &Config{
+		Listeners: listeners,
+		Admin: Admin{
+			AccessLogPath: DefaultAccessLog,
+			Port:          mesh.AdminPort,
+		},
+		ClusterManager: ClusterManager{
+			Clusters: clusters,
+			SDS: SDS{
+				Cluster:        buildSDSCluster(mesh),
+				RefreshDelayMs: 1000,
+			},
+		},
+	}
+}
@@ -16,7 +16,6 @@ package envoy
 
 import (
 	"fmt"
-	"reflect"
 	"sort"
 	"strconv"
 	"time"
@@ -29,10 +28,10 @@ import (
 )
 
 type ingressWatcher struct {
-	agent     Agent
-	discovery model.ServiceDiscovery
-	registry  *model.IstioRegistry
-	mesh      *MeshConfig
+	agent       Agent
+	snapshotter *model.Snapshotter
+	mesh        *MeshConfig
+	debouncer   *debouncer
 }
 
 // NewIngressWatcher creates a new ingress watcher instance with an agent
@@ -40,14 +39,14 @@ func NewIngressWatcher(discovery model.ServiceDiscovery, ctl model.Controller,
 	registry *model.IstioRegistry, mesh *MeshConfig, identity *ProxyNode) (Watcher, error) {
 
 	out := &ingressWatcher{
-		agent:     NewAgent(mesh.BinaryPath, mesh.ConfigPath, identity.Name),
-		discovery: discovery,
-		registry:  registry,
-		mesh:      mesh,
+		agent:       NewAgent(mesh.BinaryPath, mesh.ConfigPath, identity.Name),
+		snapshotter: model.NewSnapshotter(discovery, registry, model.IstioConfig),
+		mesh:        mesh,
 	}
+	out.debouncer = newDebouncer(out.reload, mesh.ReloadQuietPeriod, mesh.ReloadMaxDelay)
 
 	err := ctl.AppendConfigHandler(model.IngressRule,
-		func(model.Key, proto.Message, model.Event) { out.reload() })
+		func(model.Key, proto.Message, model.Event) { out.debouncer.trigger() })
 
 	if err != nil {
 		return nil, err
@@ -55,16 +54,22 @@ func NewIngressWatcher(discovery model.ServiceDiscovery, ctl model.Controller,
 	return out, nil
 }
 
+// Run implements a watcher operation
+func (w *ingressWatcher) Run(stop chan struct{}) {
+	w.debouncer.run(stop)
+}
+
 func (w *ingressWatcher) reload() {
-	config, err := w.generateConfig()
+	snapshot := w.snapshotter.Snapshot(nil)
+	config, err := w.generateConfig(&model.IstioRegistry{ConfigRegistry: snapshot})
 	if err != nil {
-		glog.Warningf("Failed to generate Envoy configuration: %v", err)
+		glog.Warningf("Failed to generate Envoy configuration version %d: %v", snapshot.Version, err)
 		return
 	}
+	config.Version = snapshot.Version
 
-	current := w.agent.ActiveConfig()
-	if reflect.DeepEqual(config, current) {
-		glog.V(2).Info("Configuration is identical, skipping reload")
+	if sameConfig(config, w.agent.ActiveConfig()) {
+		glog.V(2).Infof("Configuration version %d is identical, skipping reload", config.Version)
 		return
 	}
 
@@ -80,9 +85,9 @@ func (w *ingressWatcher) reload() {
 	time.Sleep(256 * time.Millisecond)
 }
 
-func (w *ingressWatcher) generateConfig() (*Config, error) {
+func (w *ingressWatcher) generateConfig(registry *model.IstioRegistry) (*Config, error) {
 	// TODO: Configurable namespace?
-	rules := w.registry.IngressRules("")
+	rules := registry.IngressRules("")
 
 	// Phase 1: group rules by host
 	rulesByHost := make(map[string][]*config.RouteRule, len(rules))
@@ -120,6 +125,7 @@ func (w *ingressWatcher) generateConfig() (*Config, error) {
 	sort.Sort(HostsByName(vhosts))
 
 	rConfig := &RouteConfig{VirtualHosts: vhosts}
+	insertRoutePolicies(registry, rConfig)
 
 	httpListener := &Listener{
 		Port:       80,
@@ -128,7 +134,7 @@ func (w *ingressWatcher) generateConfig() (*Config, error) {
 			{
 				Type: "read",
 				Name: HTTPConnectionManager,
-				Config: NetworkFilterConfig{
+				Config: &NetworkFilterConfig{
 					CodecType:   "auto",
 					StatPrefix:  "http",
 					AccessLog:   []AccessLog{{Path: DefaultAccessLog}},
@@ -175,16 +181,7 @@ func buildIngressRoute(rule *config.RouteRule) *Route {
 
 	if rule.Match != nil {
 		if uri, ok := rule.Match.Http[HeaderURI]; ok {
-			switch m := uri.MatchType.(type) {
-			case *config.StringMatch_Exact:
-				route.Path = m.Exact
-				route.Prefix = ""
-			case *config.StringMatch_Prefix:
-				route.Path = ""
-				route.Prefix = m.Prefix
-			case *config.StringMatch_Regex:
-				glog.Warningf("Unsupported route match condition: regex")
-			}
+			insertURIMatch(route, uri)
 		}
 	}
 
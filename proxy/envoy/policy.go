@@ -18,6 +18,10 @@
 package envoy
 
 import (
+	"time"
+
+	"github.com/golang/glog"
+
 	"istio.io/manager/model"
 	proxyconfig "istio.io/manager/model/proxy/alphav1/config"
 )
@@ -26,13 +30,13 @@ import (
 
 func insertMixerFilter(listeners []*Listener, mixer string) {
 	for _, l := range listeners {
-		for _, http := range l.Filters {
-			if http.Name == HTTPConnectionManager {
-				http.Config.Filters = append([]Filter{{
+		for _, filter := range l.Filters {
+			if http, ok := filter.Config.(*NetworkFilterConfig); ok {
+				http.Filters = append([]Filter{{
 					Type:   "both",
 					Name:   "mixer",
 					Config: &FilterMixerConfig{MixerServer: mixer},
-				}}, http.Config.Filters...)
+				}}, http.Filters...)
 			}
 		}
 	}
@@ -52,9 +56,108 @@ func insertDestinationPolicy(config *model.IstioRegistry, cluster *Cluster) {
 					cluster.LbType = "random"
 				}
 			}
+
+			if cb := policy.GetCircuitBreaker().GetSimpleCb(); cb != nil {
+				insertCircuitBreaker(cluster, cb)
+			}
+		}
+	}
+}
+
+// insertCircuitBreaker applies a simple circuit breaker policy to a cluster.
+// The consecutive errors open the circuit by ejecting the host with the
+// outlier detection for the sleep window. The detection interval is in seconds.
+func insertCircuitBreaker(cluster *Cluster, cb *proxyconfig.CircuitBreaker_SimpleCircuitBreakerPolicy) {
+	cluster.MaxRequestsPerConnection = int(cb.HttpMaxRequestsPerConnection)
+	if cb.MaxConnections > 0 || cb.HttpMaxPendingRequests > 0 || cb.HttpMaxRequests > 0 {
+		cluster.CircuitBreaker = &CircuitBreaker{
+			Default: DefaultCBPriority{
+				MaxConnections:     int(cb.MaxConnections),
+				MaxPendingRequests: int(cb.HttpMaxPendingRequests),
+				MaxRequests:        int(cb.HttpMaxRequests),
+			},
+		}
+	}
+	if cb.HttpConsecutiveErrors > 0 {
+		cluster.OutlierDetection = &OutlierDetection{
+			ConsecutiveError: int(cb.HttpConsecutiveErrors),
+			IntervalMS:       int(cb.HttpDetectionInterval) * 1000,
+		}
+		if window, err := time.ParseDuration(cb.SleepWindow); err == nil {
+			cluster.OutlierDetection.BaseEjectionTimeMS = int(window / time.Millisecond)
+		}
+	}
+}
+
+// insertRoutePolicies applies the timeout and retry policies to the routes of
+// a route config
+func insertRoutePolicies(config *model.IstioRegistry, routeConfig *RouteConfig) {
+	for _, host := range routeConfig.VirtualHosts {
+		for _, route := range host.Routes {
+			insertRoutePolicy(config, route)
+		}
+	}
+}
+
+// insertRoutePolicy applies the timeout and retry policies of the destinations
+// of a route. If the route is split across clusters, the first policy wins.
+//
+// Envoy does not rename the request headers that override the policies, so
+// the validation restricts the override headers to the Envoy headers. Envoy
+// honors these headers for the requests from within the mesh.
+func insertRoutePolicy(config *model.IstioRegistry, route *Route) {
+	for _, cluster := range route.clusters {
+		// not all clusters are for outbound services
+		if cluster.hostname == "" {
+			continue
+		}
+		for _, policy := range config.DestinationPolicies(cluster.hostname, cluster.tags) {
+			if timeout := policy.GetHttpTimeout().GetSimpleTimeout(); timeout != nil && route.TimeoutMS == 0 {
+				route.TimeoutMS = int(timeout.TimeoutSeconds * 1000)
+			}
+			if retry := policy.GetHttpRetry().GetSimpleRetry(); retry != nil && route.RetryPolicy == nil {
+				route.RetryPolicy = &RetryPolicy{
+					Policy:     DefaultRetryOn,
+					NumRetries: int(retry.Attempts),
+				}
+			}
 		}
+	}
+}
+
+// insertZoneAwareRouting turns on zone aware routing if a destination policy
+// of an outbound cluster requests it. Envoy compares the zones of the hosts of
+// the local cluster, which lists the instances of the service of the proxy,
+// with the zones of the upstream hosts. The routing applies to all the
+// clusters of the proxy once it is turned on, which is why the validation
+// rejects zone aware policies that select tags.
+func insertZoneAwareRouting(instances []*model.ServiceInstance, config *model.IstioRegistry, conf *Config) {
+	requested := false
+	for _, cluster := range conf.ClusterManager.Clusters {
+		if cluster.hostname == "" {
+			continue
+		}
+		for _, policy := range config.DestinationPolicies(cluster.hostname, cluster.tags) {
+			requested = requested || policy.ZoneAwareRouting
+		}
+	}
+	if !requested {
+		return
+	}
 
+	for _, instance := range instances {
+		zone := instance.Endpoint.Locality.Zone
+		if zone == "" {
+			continue
+		}
+		local := buildOutboundCluster(instance.Service.Hostname, instance.Endpoint.ServicePort, nil)
+		clusters := Clusters(append(conf.ClusterManager.Clusters, local))
+		conf.ClusterManager.Clusters = clusters.Normalize()
+		conf.ClusterManager.LocalClusterName = local.Name
+		conf.ServiceZone = zone
+		return
 	}
+	glog.V(2).Info("Zone aware routing requires a local service instance with a zone")
 }
 
 // buildFaultFilters builds a list of fault filters for the http route
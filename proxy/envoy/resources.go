@@ -15,7 +15,9 @@
 package envoy
 
 import (
+	"fmt"
 	"sort"
+	"time"
 
 	"istio.io/manager/model"
 )
@@ -38,6 +40,10 @@ type MeshConfig struct {
 	RuntimePath string
 	// Envoy access log path
 	AccessLogPath string
+	// ReloadQuietPeriod is the period without events that triggers a reload
+	ReloadQuietPeriod time.Duration
+	// ReloadMaxDelay is the maximum delay of a reload after an event
+	ReloadMaxDelay time.Duration
 }
 
 // TODO: these values used in the Envoy configuration will be configurable
@@ -47,11 +53,24 @@ const (
 	DefaultAccessLog = "/dev/stdout"
 	LbTypeRoundRobin = "round_robin"
 
+	// DefaultRetryOn lists the failures retried by a retry policy
+	DefaultRetryOn = "5xx,connect-failure,refused-stream"
+
+	// EgressPort is the port of the egress proxy listener
+	EgressPort = 80
+
 	// HTTPConnectionManager is the name of HTTP filter
 	HTTPConnectionManager = "http_connection_manager"
 
+	// TCPProxyFilter is the name of the TCP proxy filter
+	TCPProxyFilter = "tcp_proxy"
+
 	// URI HTTP header
 	HeaderURI = "uri"
+
+	// HeaderPath is the HTTP/2 pseudo-header of the request path, which Envoy
+	// also sets for HTTP/1.1 requests
+	HeaderPath = ":path"
 )
 
 // Config defines the schema for Envoy JSON configuration format
@@ -60,6 +79,14 @@ type Config struct {
 	Listeners      []*Listener    `json:"listeners"`
 	Admin          Admin          `json:"admin"`
 	ClusterManager ClusterManager `json:"cluster_manager"`
+
+	// ServiceZone is the zone of the proxy, passed to Envoy on the command line
+	ServiceZone string `json:"-"`
+
+	// Version is the version of the registry snapshot the configuration is
+	// generated from. Envoy rejects unknown fields, so the version is stamped
+	// into the name of the configuration file instead of its content.
+	Version uint64 `json:"-"`
 }
 
 // RootRuntime definition.
@@ -208,7 +235,7 @@ type AccessLog struct {
 	Filter string `json:"filter,omitempty"`
 }
 
-// NetworkFilterConfig definition
+// NetworkFilterConfig definition of the HTTP connection manager filter
 type NetworkFilterConfig struct {
 	CodecType         string       `json:"codec_type"`
 	StatPrefix        string       `json:"stat_prefix"`
@@ -219,11 +246,67 @@ type NetworkFilterConfig struct {
 	Cluster           string       `json:"cluster,omitempty"`
 }
 
-// NetworkFilter definition
+// TCPRoute definition
+// See: https://lyft.github.io/envoy/docs/configuration/network_filters/tcp_proxy_filter.html#route
+type TCPRoute struct {
+	Cluster           string   `json:"cluster"`
+	DestinationIPList []string `json:"destination_ip_list,omitempty"`
+	DestinationPorts  string   `json:"destination_ports,omitempty"`
+	SourceIPList      []string `json:"source_ip_list,omitempty"`
+	SourcePorts       string   `json:"source_ports,omitempty"`
+
+	// cluster is the referenced cluster; the field is special and used only to
+	// aggregate cluster information after composing routes
+	cluster *Cluster
+}
+
+// hasCondition is true if the route selects the connections by address
+func (route *TCPRoute) hasCondition() bool {
+	return len(route.DestinationIPList) > 0 || len(route.SourceIPList) > 0
+}
+
+// conditionKey identifies the connections that the route selects
+func (route *TCPRoute) conditionKey() string {
+	return fmt.Sprintf("%v %s %v %s", route.DestinationIPList, route.DestinationPorts,
+		route.SourceIPList, route.SourcePorts)
+}
+
+// TCPRouteConfig definition
+type TCPRouteConfig struct {
+	Routes []*TCPRoute `json:"routes"`
+}
+
+// Merge operation appends the routes of the second route config, so that the
+// routes of the first one take priority
+func (rc *TCPRouteConfig) merge(that *TCPRouteConfig) *TCPRouteConfig {
+	out := &TCPRouteConfig{}
+	out.Routes = append(out.Routes, rc.Routes...)
+	out.Routes = append(out.Routes, that.Routes...)
+	return out
+}
+
+// Clusters aggregates clusters across routes
+func (rc *TCPRouteConfig) clusters() []*Cluster {
+	out := make([]*Cluster, 0)
+	for _, route := range rc.Routes {
+		out = append(out, route.cluster)
+	}
+	return out
+}
+
+// TCPProxyFilterConfig definition
+// See: https://lyft.github.io/envoy/docs/configuration/network_filters/tcp_proxy_filter.html
+type TCPProxyFilterConfig struct {
+	StatPrefix  string          `json:"stat_prefix"`
+	RouteConfig *TCPRouteConfig `json:"route_config"`
+}
+
+// NetworkFilter definition. The configuration is either *NetworkFilterConfig
+// for the HTTP connection manager or *TCPProxyFilterConfig for the TCP proxy.
 type NetworkFilter struct {
-	Type   string              `json:"type"`
-	Name   string              `json:"name"`
-	Config NetworkFilterConfig `json:"config"`
+	Type   string      `json:"type"`
+	Name   string      `json:"name"`
+	Config interface{} `json:"config"`
 }
 
 // Listener definition
@@ -247,6 +330,19 @@ func (hosts RouteConfigs) EnsurePort(port int) *RouteConfig {
 	return config
 }
 
+// TCPRouteConfigs provides TCP routes by port
+type TCPRouteConfigs map[int]*TCPRouteConfig
+
+// EnsurePort creates a TCP route config if necessary
+func (configs TCPRouteConfigs) EnsurePort(port int) *TCPRouteConfig {
+	config, ok := configs[port]
+	if !ok {
+		config = &TCPRouteConfig{}
+		configs[port] = config
+	}
+	return config
+}
+
 // Admin definition
 type Admin struct {
 	AccessLogPath string `json:"access_log_path"`
@@ -268,8 +364,9 @@ type Cluster struct {
 	MaxRequestsPerConnection int               `json:"max_requests_per_connection,omitempty"`
 	Hosts                    []Host            `json:"hosts,omitempty"`
 	Features                 string            `json:"features,omitempty"`
-	CircuitBreaker           *CircuitBreaker   `json:"circuit_breaker,omitempty"`
+	CircuitBreaker           *CircuitBreaker   `json:"circuit_breakers,omitempty"`
 	OutlierDetection         *OutlierDetection `json:"outlier_detection,omitempty"`
+	SSLContext               *SSLContext       `json:"ssl_context,omitempty"`
 
 	// special values used by the post-processing passes for outbound clusters
 	hostname string
@@ -277,9 +374,20 @@ type Cluster struct {
 	tags     model.Tags
 }
 
+// SSLContext definition for the upstream connections of a cluster
+// See: https://lyft.github.io/envoy/docs/configuration/cluster_manager/cluster_ssl.html
+type SSLContext struct {
+	SNI string `json:"sni,omitempty"`
+}
+
 // CircuitBreaker definition
 // See: https://lyft.github.io/envoy/docs/configuration/cluster_manager/cluster_circuit_breakers.html#circuit-breakers
 type CircuitBreaker struct {
+	Default DefaultCBPriority `json:"default"`
+}
+
+// DefaultCBPriority defines the circuit breaker for the default routing priority
+type DefaultCBPriority struct {
 	MaxConnections     int `json:"max_connections,omitempty"`
 	MaxPendingRequests int `json:"max_pending_requests,omitempty"`
 	MaxRequests        int `json:"max_requests,omitempty"`
@@ -354,13 +462,41 @@ func (s HostsByName) Less(i, j int) bool {
 	return s[i].Name < s[j].Name
 }
 
-// RoutesByPath sorts routes by their path and/or prefix, such that:
-// - Exact path routes are "less than" than prefix path routes
+// ServicesByName sorts services by hostname
+type ServicesByName []*model.Service
+
+func (s ServicesByName) Len() int {
+	return len(s)
+}
+
+func (s ServicesByName) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s ServicesByName) Less(i, j int) bool {
+	return s[i].Hostname < s[j].Hostname
+}
+
+// pathRegex returns the regular expression of the path match condition if any
+func (route *Route) pathRegex() (string, bool) {
+	for _, header := range route.Headers {
+		if header.Name == HeaderPath && header.Regex {
+			return header.Value, true
+		}
+	}
+	return "", false
+}
+
+// RoutesByPath sorts routes by their path, regex and/or prefix, such that:
+// - Exact path routes are "less than" regex path routes
+// - Regex path routes are "less than" prefix path routes
 // - Exact path routes are sorted lexicographically
+// - Regex path routes are sorted lexicographically
 // - Prefix path routes are sorted anti-lexicographically
 //
 // This order ensures that prefix path routes do not shadow more
-// specific routes which share the same prefix.
+// specific routes which share the same prefix. Regex path routes match
+// under the root prefix, and precede all prefix path routes.
 type RoutesByPath []*Route
 
 func (r RoutesByPath) Len() int {
@@ -377,17 +513,48 @@ func (r RoutesByPath) Less(i, j int) bool {
 			// i and j are both path
 			return r[i].Path < r[j].Path
 		}
-		// i is path and j is prefix => i is "less than" j
+		// i is path and j is regex or prefix => i is "less than" j
 		return true
 	}
 	if r[j].Path != "" {
-		// i is prefix nad j is path => j is "less than" i
+		// i is regex or prefix and j is path => j is "less than" i
+		return false
+	}
+	ri, iRegex := r[i].pathRegex()
+	rj, jRegex := r[j].pathRegex()
+	if iRegex {
+		if jRegex {
+			// i and j are both regex
+			return ri < rj
+		}
+		// i is regex and j is prefix => i is "less than" j
+		return true
+	}
+	if jRegex {
+		// i is prefix and j is regex => j is "less than" i
 		return false
 	}
 	// i and j are both prefix
 	return r[i].Prefix > r[j].Prefix
 }
 
+// TCPRoutesByCondition sorts the TCP routes that select connections by address
+// ahead of the routes that match any connection. The sort must be stable to
+// preserve the order of the routes otherwise.
+type TCPRoutesByCondition []*TCPRoute
+
+func (r TCPRoutesByCondition) Len() int {
+	return len(r)
+}
+
+func (r TCPRoutesByCondition) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+}
+
+func (r TCPRoutesByCondition) Less(i, j int) bool {
+	return r[i].hasCondition() && !r[j].hasCondition()
+}
+
 // Headers sorts headers
 type Headers []Header
 
@@ -418,8 +585,9 @@ type SDS struct {
 
 // ClusterManager definition
 type ClusterManager struct {
-	Clusters []*Cluster `json:"clusters"`
-	SDS      SDS        `json:"sds"`
+	Clusters         []*Cluster `json:"clusters"`
+	SDS              SDS        `json:"sds"`
+	LocalClusterName string     `json:"local_cluster_name,omitempty"`
 }
 
 // ByName implements sort
@@ -19,6 +19,8 @@ package envoy
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/golang/glog"
@@ -76,11 +78,41 @@ func buildOutboundCluster(hostname string, port *model.Port, tags model.Tags) *C
 	return cluster
 }
 
+// insertExternalServices rewrites the outbound clusters of the external services
+// to resolve the service hostnames with DNS instead of the discovery service
+func insertExternalServices(services []*model.Service, clusters Clusters) {
+	external := make(map[string]*model.External)
+	for _, svc := range services {
+		if svc.External != nil {
+			external[svc.Hostname] = svc.External
+		}
+	}
+	for _, cluster := range clusters {
+		ext, ok := external[cluster.hostname]
+		if !ok {
+			continue
+		}
+		cluster.ServiceName = ""
+		cluster.Type = "strict_dns"
+		if ext.LogicalDNS {
+			cluster.Type = "logical_dns"
+		}
+		cluster.Hosts = []Host{{URL: fmt.Sprintf("tcp://%s:%d", cluster.hostname, cluster.port.Port)}}
+		if ext.TLSOrigination {
+			cluster.SSLContext = &SSLContext{SNI: cluster.hostname}
+		}
+	}
+}
+
 // buildHTTPRoutes assembles all routes for the hostname destination
 func buildHTTPRoutes(hostname string, port *model.Port, config *model.IstioRegistry) []*Route {
 	routes := make([]*Route, 0)
 	for _, rule := range config.DestinationRouteRules(hostname) {
-		// TODO: rule applies always, need to check if it's actually HTTP rule
+		// rules with only layer 4 conditions do not apply to HTTP traffic
+		match := rule.GetMatch()
+		if len(match.GetHttp()) == 0 && (match.GetTcp() != nil || match.GetUdp() != nil) {
+			continue
+		}
 		routes = append(routes, buildHTTPRoute(rule, port))
 	}
 	cluster := buildOutboundCluster(hostname, port, nil)
@@ -99,16 +131,7 @@ func buildHTTPRoute(rule *config.RouteRule, port *model.Port) *Route {
 		route.Headers = buildHeaders(rule.Match.Http)
 
 		if uri, ok := rule.Match.Http[HeaderURI]; ok {
-			switch m := uri.MatchType.(type) {
-			case *config.StringMatch_Exact:
-				route.Path = m.Exact
-				route.Prefix = ""
-			case *config.StringMatch_Prefix:
-				route.Path = ""
-				route.Prefix = m.Prefix
-			case *config.StringMatch_Regex:
-				glog.Warningf("Unsupported route match condition: regex")
-			}
+			insertURIMatch(route, uri)
 		}
 	}
 
@@ -139,6 +162,132 @@ func buildHTTPRoute(rule *config.RouteRule, port *model.Port) *Route {
 	return route
 }
 
+// buildTCPRoutes assembles the TCP routes for a service port: the route rules
+// with a TCP condition followed by the default route. TCP connections carry no
+// hostname, so the routes select connections by the service address.
+func buildTCPRoutes(service *model.Service, port *model.Port, config *model.IstioRegistry) []*TCPRoute {
+	addresses := make([]string, 0)
+	if service.Address != "" {
+		addresses = append(addresses, service.Address)
+	}
+
+	routes := make([]*TCPRoute, 0)
+	for _, rule := range config.DestinationRouteRules(service.Hostname) {
+		tcp := rule.GetMatch().GetTcp()
+		if tcp == nil {
+			continue
+		}
+
+		// the TCP proxy forwards a connection to a single cluster
+		if len(rule.Route) > 1 {
+			glog.Warningf("Unsupported weighted TCP routes in a rule for %s", rule.Destination)
+			continue
+		}
+		destination := rule.Destination
+		var tags model.Tags
+		if len(rule.Route) == 1 {
+			if rule.Route[0].Destination != "" {
+				destination = rule.Route[0].Destination
+			}
+			tags = rule.Route[0].Tags
+		}
+
+		destinations := addresses
+		if len(tcp.DestinationSubnet) > 0 {
+			destinations = tcp.DestinationSubnet
+		} else if len(addresses) == 0 {
+			// a route without destinations would capture all connections to the port
+			continue
+		}
+		route := buildTCPRoute(buildOutboundCluster(destination, port, tags), destinations, port.Port)
+		route.SourceIPList = buildSubnets(tcp.SourceSubnet)
+		routes = append(routes, route)
+	}
+
+	// the connections to a service without an address cannot be told apart from
+	// the connections to other destinations on the port
+	if len(addresses) == 0 {
+		glog.Warningf("Unsupported TCP port %d of service %s without an address", port.Port, service.Hostname)
+		return routes
+	}
+	cluster := buildOutboundCluster(service.Hostname, port, nil)
+	routes = append(routes, buildTCPRoute(cluster, addresses, port.Port))
+	return routes
+}
+
+// uniqueTCPRoutes drops the routes that select the same connections as an
+// earlier route, which Envoy would never use, e.g. route rules with the same
+// destination subnets.
+func uniqueTCPRoutes(port int, routes []*TCPRoute) []*TCPRoute {
+	out := make([]*TCPRoute, 0, len(routes))
+	selected := make(map[string]*TCPRoute, len(routes))
+	for _, route := range routes {
+		key := route.conditionKey()
+		if first, exists := selected[key]; exists {
+			glog.Warningf("TCP route to %s on port %d overlaps with the route to %s, dropping it",
+				route.Cluster, port, first.Cluster)
+			continue
+		}
+		selected[key] = route
+		out = append(out, route)
+	}
+	return out
+}
+
+// buildTCPRoute creates a TCP route to a cluster for the connections to the
+// destination addresses and port
+func buildTCPRoute(cluster *Cluster, destinations []string, port int) *TCPRoute {
+	return &TCPRoute{
+		Cluster:           cluster.Name,
+		DestinationIPList: buildSubnets(destinations),
+		DestinationPorts:  strconv.Itoa(port),
+		cluster:           cluster,
+	}
+}
+
+// buildSubnets converts IP addresses with optional prefix lengths to the
+// sorted list of subnets in CIDR notation
+func buildSubnets(addresses []string) []string {
+	if len(addresses) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		if !strings.Contains(address, "/") {
+			if strings.Contains(address, ":") {
+				address += "/128"
+			} else {
+				address += "/32"
+			}
+		}
+		out = append(out, address)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// insertURIMatch sets the path condition of a route from a URI match. Envoy
+// routes match the path exactly or by prefix, so a regular expression is
+// matched against the whole :path header, including the query string, under
+// the root prefix.
+func insertURIMatch(route *Route, uri *config.StringMatch) {
+	switch m := uri.MatchType.(type) {
+	case *config.StringMatch_Exact:
+		route.Path = m.Exact
+		route.Prefix = ""
+	case *config.StringMatch_Prefix:
+		route.Path = ""
+		route.Prefix = m.Prefix
+	case *config.StringMatch_Regex:
+		route.Path = ""
+		route.Prefix = "/"
+		route.Headers = append(route.Headers, Header{Name: HeaderPath, Value: m.Regex, Regex: true})
+		sort.Sort(Headers(route.Headers))
+	default:
+		glog.Warningf("Missing URI match type: %#v", uri.MatchType)
+	}
+}
+
 func buildSDSCluster(mesh *MeshConfig) *Cluster {
 	return &Cluster{
 		Name:             "sds",
@@ -23,8 +23,11 @@ import (
 	"istio.io/manager/model"
 )
 
-// Watcher observes service registry and triggers a reload on a change
+// Watcher observes service registry and triggers a reload on a change.
+// Bursts of events are coalesced into a single reload.
 type Watcher interface {
+	// Run reloads the proxy on the events until a signal is received
+	Run(stop chan struct{})
 }
 
 // ProxyNode provides the local proxy node name and IP address
@@ -34,11 +37,11 @@ type ProxyNode struct {
 }
 
 type watcher struct {
-	agent     Agent
-	discovery model.ServiceDiscovery
-	registry  *model.IstioRegistry
-	mesh      *MeshConfig
-	addrs     map[string]bool
+	agent       Agent
+	snapshotter *model.Snapshotter
+	mesh        *MeshConfig
+	addrs       map[string]bool
+	debouncer   *debouncer
 }
 
 // NewWatcher creates a new watcher instance with an agent
@@ -51,23 +54,28 @@ func NewWatcher(discovery model.ServiceDiscovery, ctl model.Controller,
 	glog.V(2).Infof("Local instance address: %#v", addrs)
 
 	out := &watcher{
-		agent:     NewAgent(mesh.BinaryPath, mesh.ConfigPath, identity.Name),
-		discovery: discovery,
-		registry:  registry,
-		mesh:      mesh,
-		addrs:     addrs,
+		agent:       NewAgent(mesh.BinaryPath, mesh.ConfigPath, identity.Name),
+		snapshotter: model.NewSnapshotter(discovery, registry, model.IstioConfig),
+		mesh:        mesh,
+		addrs:       addrs,
 	}
+	out.debouncer = newDebouncer(out.reload, mesh.ReloadQuietPeriod, mesh.ReloadMaxDelay)
 
-	if err := ctl.AppendServiceHandler(func(*model.Service, model.Event) { out.reload() }); err != nil {
+	if err := ctl.AppendServiceHandler(func(*model.Service, model.Event) { out.debouncer.trigger() }); err != nil {
 		return nil, err
 	}
 
-	// TODO: restrict the notification callback to co-located instances (e.g. with the same IP)
-	if err := ctl.AppendInstanceHandler(func(*model.ServiceInstance, model.Event) { out.reload() }); err != nil {
+	// only the co-located instances affect the configuration, since the
+	// outbound clusters obtain their hosts from the discovery service
+	if err := ctl.AppendInstanceHandler(func(instance *model.ServiceInstance, _ model.Event) {
+		if addrs[instance.Endpoint.Address] {
+			out.debouncer.trigger()
+		}
+	}); err != nil {
 		return nil, err
 	}
 
-	handler := func(model.Key, proto.Message, model.Event) { out.reload() }
+	handler := func(model.Key, proto.Message, model.Event) { out.debouncer.trigger() }
 
 	if err := ctl.AppendConfigHandler(model.RouteRule, handler); err != nil {
 		return nil, err
@@ -80,15 +88,21 @@ func NewWatcher(discovery model.ServiceDiscovery, ctl model.Controller,
 	return out, nil
 }
 
+// Run implements a watcher operation
+func (w *watcher) Run(stop chan struct{}) {
+	w.debouncer.run(stop)
+}
+
 func (w *watcher) reload() {
+	snapshot := w.snapshotter.Snapshot(w.addrs)
 	config := Generate(
-		w.discovery.HostInstances(w.addrs),
-		w.discovery.Services(),
-		w.registry, w.mesh)
-	current := w.agent.ActiveConfig()
+		snapshot.HostInstances(w.addrs),
+		snapshot.Services(),
+		&model.IstioRegistry{ConfigRegistry: snapshot}, w.mesh)
+	config.Version = snapshot.Version
 
-	if reflect.DeepEqual(config, current) {
-		glog.V(2).Info("Configuration is identical, skipping reload")
+	if sameConfig(config, w.agent.ActiveConfig()) {
+		glog.V(2).Infof("Configuration version %d is identical, skipping reload", config.Version)
 		return
 	}
 
@@ -103,3 +117,13 @@ func (w *watcher) reload() {
 	// the Reload() function.
 	time.Sleep(256 * time.Millisecond)
 }
+
+// sameConfig compares the configurations regardless of the snapshot versions
+func sameConfig(config, current *Config) bool {
+	if config == nil || current == nil {
+		return config == current
+	}
+	a, b := *config, *current
+	a.Version, b.Version = 0, 0
+	return reflect.DeepEqual(a, b)
+}
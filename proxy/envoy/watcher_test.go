@@ -0,0 +1,635 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"istio.io/manager/model"
+	"istio.io/manager/model/proxy/alphav1/config"
+	"istio.io/manager/platform/memory"
+)
+
+var testMesh = &MeshConfig{
+	DiscoveryAddress: "manager:8080",
+	ProxyPort:        5001,
+	AdminPort:        5000,
+}
+
+func TestWatcherHandlers(t *testing.T) {
+	ctl := makeTestController(t)
+	registry := &model.IstioRegistry{ConfigRegistry: ctl}
+	identity := &ProxyNode{Name: "test", IP: "10.0.0.1"}
+	if _, err := NewWatcher(ctl, ctl, registry, testMesh, identity); err != nil {
+		t.Error(err)
+	}
+	if _, err := NewIngressWatcher(ctl, ctl, registry, testMesh, identity); err != nil {
+		t.Error(err)
+	}
+	if _, err := NewEgressWatcher(ctl, ctl, registry, testMesh, identity); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWatcherInstanceEvents(t *testing.T) {
+	ctl := makeTestController(t)
+	registry := &model.IstioRegistry{ConfigRegistry: ctl}
+	out, err := NewWatcher(ctl, ctl, registry, testMesh, &ProxyNode{Name: "test", IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := out.(*watcher)
+
+	// the handlers are called in order
+	done := make(chan struct{})
+	if err = ctl.AppendInstanceHandler(func(*model.ServiceInstance, model.Event) { done <- struct{}{} }); err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go ctl.Run(stop)
+
+	if err = ctl.AddInstance(makeTestInstance("10.0.0.9", model.Tags{"version": "v1"})); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	if len(w.debouncer.events) != 0 {
+		t.Error("unexpected reload for an instance of another proxy")
+	}
+
+	if err = ctl.AddInstance(makeTestInstance("10.0.0.1", model.Tags{"version": "v2"})); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	if len(w.debouncer.events) != 1 {
+		t.Error("missing reload for a co-located instance")
+	}
+}
+
+func TestSameConfig(t *testing.T) {
+	ctl := makeTestController(t)
+	snapshotter := model.NewSnapshotter(ctl, ctl, model.IstioConfig)
+	generate := func() *Config {
+		addrs := map[string]bool{"10.0.0.1": true}
+		snapshot := snapshotter.Snapshot(addrs)
+		out := Generate(snapshot.HostInstances(addrs), snapshot.Services(),
+			&model.IstioRegistry{ConfigRegistry: snapshot}, testMesh)
+		out.Version = snapshot.Version
+		return out
+	}
+	first, second := generate(), generate()
+	if first.Version >= second.Version {
+		t.Errorf("snapshot versions %d and %d do not increase", first.Version, second.Version)
+	}
+	if !sameConfig(first, second) {
+		t.Error("configurations from different snapshots of the same registry differ")
+	}
+	if sameConfig(first, nil) {
+		t.Error("configuration is the same as no configuration")
+	}
+	if out := configFile("/etc/envoy", 3, second.Version); out != "/etc/envoy/envoy-rev3-v2.json" {
+		t.Errorf("configFile() => got %q, want the epoch and the version in the name", out)
+	}
+}
+
+func TestGenerateSidecar(t *testing.T) {
+	ctl := makeTestController(t)
+	registry := &model.IstioRegistry{ConfigRegistry: ctl}
+	rule := &config.RouteRule{
+		Destination: testHostname,
+		Route: []*config.DestinationWeight{
+			{Tags: map[string]string{"version": "v1"}, Weight: 100},
+		},
+	}
+	key := model.Key{Kind: model.RouteRule, Name: "v1", Namespace: "default"}
+	if _, err := ctl.Post(key, rule); err != nil {
+		t.Fatal(err)
+	}
+
+	out := Generate(ctl.HostInstances(map[string]bool{"10.0.0.2": true}), ctl.Services(), registry, testMesh)
+
+	// inbound port 8080, outbound port 80, and the proxy port
+	if len(out.Listeners) != 3 {
+		t.Fatalf("Generate() => got %d listeners, want 3", len(out.Listeners))
+	}
+	v1 := buildOutboundCluster(testHostname, testHTTPPort, model.Tags{"version": "v1"}).Name
+	found := false
+	for _, cluster := range out.ClusterManager.Clusters {
+		if cluster.Name == v1 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Generate() => missing cluster %q in %v", v1, out.ClusterManager.Clusters)
+	}
+}
+
+func TestGenerateRegexRoutes(t *testing.T) {
+	ctl := makeTestController(t)
+	registry := &model.IstioRegistry{ConfigRegistry: ctl}
+	regex := &config.StringMatch{MatchType: &config.StringMatch_Regex{Regex: "/api/v[0-9]+/.*"}}
+	rule := &config.RouteRule{
+		Destination: testHostname,
+		Match:       &config.MatchCondition{Http: map[string]*config.StringMatch{HeaderURI: regex}},
+		Route:       []*config.DestinationWeight{{Tags: map[string]string{"version": "v2"}}},
+	}
+	if _, err := ctl.Post(model.Key{Kind: model.RouteRule, Name: "regex", Namespace: "default"}, rule); err != nil {
+		t.Fatal(err)
+	}
+	ingress := &config.RouteRule{
+		Destination: testHostname,
+		Match:       &config.MatchCondition{Http: map[string]*config.StringMatch{HeaderURI: regex}},
+		Route: []*config.DestinationWeight{{
+			Tags: map[string]string{"servicePort.port": "80", "servicePort.name": "http", "servicePort.protocol": "HTTP"},
+		}},
+	}
+	catchall := &config.RouteRule{
+		Destination: testHostname,
+		Match: &config.MatchCondition{Http: map[string]*config.StringMatch{
+			HeaderURI: {MatchType: &config.StringMatch_Prefix{Prefix: "/"}},
+		}},
+		Route: ingress.Route,
+	}
+	for name, value := range map[string]*config.RouteRule{"regex": ingress, "catchall": catchall} {
+		if _, err := ctl.Post(model.Key{Kind: model.IngressRule, Name: name, Namespace: "default"}, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := Header{Name: HeaderPath, Value: "/api/v[0-9]+/.*", Regex: true}
+
+	// the regex route precedes the default route of the sidecar
+	out := Generate(nil, ctl.Services(), registry, testMesh)
+	var host *VirtualHost
+	for _, listener := range out.Listeners {
+		if listener.Port != testHTTPPort.Port {
+			continue
+		}
+		for _, vhost := range listener.Filters[0].Config.(*NetworkFilterConfig).RouteConfig.VirtualHosts {
+			if vhost.Name == testService.Key(testHTTPPort, nil) {
+				host = vhost
+			}
+		}
+	}
+	if host == nil || len(host.Routes) != 2 {
+		t.Fatalf("Generate() => got virtual host %#v, want the regex route and the default route", host)
+	}
+	if route := host.Routes[0]; route.Path != "" || route.Prefix != "/" ||
+		!reflect.DeepEqual(route.Headers, Headers{want}) {
+		t.Errorf("Generate() => got route %#v, want a regex match on %s", route, HeaderPath)
+	}
+
+	// the regex route precedes the catch-all prefix route of the ingress
+	w := &ingressWatcher{mesh: testMesh}
+	conf, err := w.generateConfig(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vhosts := conf.Listeners[0].Filters[0].Config.(*NetworkFilterConfig).RouteConfig.VirtualHosts
+	if len(vhosts) != 1 || len(vhosts[0].Routes) != 2 {
+		t.Fatalf("generateConfig() => got virtual hosts %#v, want a single host with two routes", vhosts)
+	}
+	if route := vhosts[0].Routes[0]; !reflect.DeepEqual(route.Headers, Headers{want}) {
+		t.Errorf("generateConfig() => got first route %#v, want a regex match on %s", route, HeaderPath)
+	}
+	if route := vhosts[0].Routes[1]; route.Prefix != "/" || len(route.Headers) != 0 {
+		t.Errorf("generateConfig() => got last route %#v, want the catch-all route", route)
+	}
+}
+
+func TestGenerateRoutePolicies(t *testing.T) {
+	ctl := makeTestController(t)
+	registry := &model.IstioRegistry{ConfigRegistry: ctl}
+	policy := &config.Destination{
+		Destination: testHostname,
+		HttpTimeout: &config.HTTPTimeout{
+			TimeoutPolicy: &config.HTTPTimeout_SimpleTimeout{
+				SimpleTimeout: &config.HTTPTimeout_SimpleTimeoutPolicy{
+					TimeoutSeconds:     2,
+					OverrideHeaderName: model.TimeoutOverrideHeader,
+				},
+			},
+		},
+		HttpRetry: &config.HTTPRetry{
+			RetryPolicy: &config.HTTPRetry_SimpleRetry{
+				SimpleRetry: &config.HTTPRetry_SimpleRetryPolicy{
+					Attempts:           2,
+					OverrideHeaderName: model.RetriesOverrideHeader,
+				},
+			},
+		},
+	}
+	if _, err := ctl.Post(model.Key{Kind: model.Destination, Name: "hello", Namespace: "default"}, policy); err != nil {
+		t.Fatal(err)
+	}
+	ingress := &config.RouteRule{
+		Destination: testHostname,
+		Route: []*config.DestinationWeight{{
+			Tags: map[string]string{"servicePort.port": "80", "servicePort.name": "http", "servicePort.protocol": "HTTP"},
+		}},
+	}
+	if _, err := ctl.Post(model.Key{Kind: model.IngressRule, Name: "hello", Namespace: "default"}, ingress); err != nil {
+		t.Fatal(err)
+	}
+	retry := &RetryPolicy{Policy: DefaultRetryOn, NumRetries: 2}
+
+	// the outbound route has the policies, and the inbound route has none
+	out := Generate(ctl.HostInstances(map[string]bool{"10.0.0.1": true}), ctl.Services(), registry, testMesh)
+	routes := 0
+	for _, listener := range out.Listeners {
+		for _, filter := range listener.Filters {
+			http, ok := filter.Config.(*NetworkFilterConfig)
+			if !ok {
+				continue
+			}
+			for _, vhost := range http.RouteConfig.VirtualHosts {
+				for _, route := range vhost.Routes {
+					routes++
+					if strings.HasPrefix(route.Cluster, InboundClusterPrefix) {
+						if route.TimeoutMS != 0 || route.RetryPolicy != nil {
+							t.Errorf("Generate() => got inbound route %#v, want no policies", route)
+						}
+					} else if route.TimeoutMS != 2000 || !reflect.DeepEqual(route.RetryPolicy, retry) {
+						t.Errorf("Generate() => got outbound route %#v, want the timeout and retry policies", route)
+					}
+				}
+			}
+		}
+	}
+	if routes != 2 {
+		t.Errorf("Generate() => got %d routes, want the inbound and outbound routes", routes)
+	}
+
+	w := &ingressWatcher{mesh: testMesh}
+	conf, err := w.generateConfig(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	route := conf.Listeners[0].Filters[0].Config.(*NetworkFilterConfig).RouteConfig.VirtualHosts[0].Routes[0]
+	if route.TimeoutMS != 2000 || !reflect.DeepEqual(route.RetryPolicy, retry) {
+		t.Errorf("generateConfig() => got route %#v, want the timeout and retry policies", route)
+	}
+}
+
+func TestGenerateTCP(t *testing.T) {
+	ctl := memory.NewController(model.IstioConfig)
+	registry := &model.IstioRegistry{ConfigRegistry: ctl}
+	port := &model.Port{Name: "mysql", Port: 3306, Protocol: model.ProtocolTCP}
+	db := &model.Service{Hostname: "db.default.svc.cluster.local", Address: "10.1.0.1", Ports: model.PortList{port}}
+	if err := ctl.AddService(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctl.AddInstance(&model.ServiceInstance{
+		Endpoint: model.NetworkEndpoint{Address: "10.0.0.2", Port: 3307, ServicePort: port},
+		Service:  db,
+		Tags:     model.Tags{"version": "v1"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	rule := &config.RouteRule{
+		Destination: db.Hostname,
+		Match: &config.MatchCondition{
+			Tcp: &config.L4MatchAttributes{SourceSubnet: []string{"10.2.0.0/16", "10.3.0.1"}},
+		},
+		Route: []*config.DestinationWeight{{Tags: model.Tags{"version": "v2"}}},
+	}
+	if _, err := ctl.Post(model.Key{Kind: model.RouteRule, Name: "db", Namespace: "default"}, rule); err != nil {
+		t.Fatal(err)
+	}
+
+	out := Generate(ctl.HostInstances(map[string]bool{"10.0.0.2": true}), ctl.Services(), registry, testMesh)
+
+	routes := make(map[int][]*TCPRoute)
+	for _, listener := range out.Listeners {
+		for _, filter := range listener.Filters {
+			if tcp, ok := filter.Config.(*TCPProxyFilterConfig); ok {
+				routes[listener.Port] = tcp.RouteConfig.Routes
+			}
+		}
+	}
+	v2 := buildOutboundCluster(db.Hostname, port, model.Tags{"version": "v2"})
+	want := map[int][]*TCPRoute{
+		3306: {
+			{
+				Cluster:           v2.Name,
+				DestinationIPList: []string{"10.1.0.1/32"},
+				DestinationPorts:  "3306",
+				SourceIPList:      []string{"10.2.0.0/16", "10.3.0.1/32"},
+			},
+			{
+				Cluster:           buildOutboundCluster(db.Hostname, port, nil).Name,
+				DestinationIPList: []string{"10.1.0.1/32"},
+				DestinationPorts:  "3306",
+			},
+		},
+		3307: {
+			{
+				Cluster:           buildInboundCluster(3307, model.ProtocolTCP).Name,
+				DestinationIPList: []string{"10.0.0.2/32"},
+				DestinationPorts:  "3307",
+			},
+		},
+	}
+	if len(routes) != len(want) {
+		t.Fatalf("Generate() => got TCP listeners on ports %v, want 3306 and 3307", routes)
+	}
+	for port, expected := range want {
+		if len(routes[port]) != len(expected) {
+			t.Errorf("Generate() => got %d TCP routes on port %d, want %d", len(routes[port]), port, len(expected))
+			continue
+		}
+		for i, route := range routes[port] {
+			if route.Cluster != expected[i].Cluster ||
+				!reflect.DeepEqual(route.DestinationIPList, expected[i].DestinationIPList) ||
+				route.DestinationPorts != expected[i].DestinationPorts ||
+				!reflect.DeepEqual(route.SourceIPList, expected[i].SourceIPList) {
+				t.Errorf("Generate() => got TCP route %#v on port %d, want %#v", route, port, expected[i])
+			}
+		}
+	}
+
+	found := false
+	for _, cluster := range out.ClusterManager.Clusters {
+		if cluster.Name == v2.Name && cluster.Type == "sds" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Generate() => missing cluster %q", v2.Name)
+	}
+}
+
+func TestGenerateTCPWithoutAddress(t *testing.T) {
+	ctl := memory.NewController(model.IstioConfig)
+	registry := &model.IstioRegistry{ConfigRegistry: ctl}
+	port := &model.Port{Name: "redis", Port: 6379, Protocol: model.ProtocolTCP}
+	for _, hostname := range []string{"b.default.svc.cluster.local", "a.default.svc.cluster.local"} {
+		if err := ctl.AddService(&model.Service{Hostname: hostname, Ports: model.PortList{port}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// services without an address would capture any connection to the port
+	out := Generate(nil, ctl.Services(), registry, testMesh)
+
+	for _, listener := range out.Listeners {
+		if listener.Port == port.Port {
+			t.Errorf("Generate() => got listener %#v, want none on port %d", listener, port.Port)
+		}
+	}
+	for _, cluster := range out.ClusterManager.Clusters {
+		if cluster.Name == buildOutboundCluster("a.default.svc.cluster.local", port, nil).Name ||
+			cluster.Name == buildOutboundCluster("b.default.svc.cluster.local", port, nil).Name {
+			t.Errorf("Generate() => got cluster %q of a service without an address", cluster.Name)
+		}
+	}
+}
+
+func TestGenerateHTTPS(t *testing.T) {
+	ctl := memory.NewController(model.IstioConfig)
+	registry := &model.IstioRegistry{ConfigRegistry: ctl}
+	port := &model.Port{Name: "https", Port: 443, Protocol: model.ProtocolHTTPS}
+	svc := &model.Service{Hostname: "secure.default.svc.cluster.local", Address: "10.1.0.2", Ports: model.PortList{port}}
+	if err := ctl.AddService(svc); err != nil {
+		t.Fatal(err)
+	}
+	policy := &config.Destination{
+		Destination: svc.Hostname,
+		LoadBalancing: &config.LoadBalancing{
+			LbPolicy: &config.LoadBalancing_Name{Name: config.LoadBalancing_LEAST_CONN},
+		},
+	}
+	if _, err := ctl.Post(model.Key{Kind: model.Destination, Name: "secure", Namespace: "default"}, policy); err != nil {
+		t.Fatal(err)
+	}
+
+	out := Generate(nil, ctl.Services(), registry, testMesh)
+
+	var tcp *TCPProxyFilterConfig
+	for _, listener := range out.Listeners {
+		if listener.Port == port.Port {
+			tcp, _ = listener.Filters[0].Config.(*TCPProxyFilterConfig)
+		}
+	}
+	if tcp == nil || len(tcp.RouteConfig.Routes) != 1 {
+		t.Fatalf("Generate() => got %#v, want a TCP proxy with a single route on port 443", tcp)
+	}
+	route := tcp.RouteConfig.Routes[0]
+	cluster := buildOutboundCluster(svc.Hostname, port, nil)
+	if route.Cluster != cluster.Name || !reflect.DeepEqual(route.DestinationIPList, []string{"10.1.0.2/32"}) {
+		t.Errorf("Generate() => got route %#v, want cluster %q for the service address", route, cluster.Name)
+	}
+
+	var found *Cluster
+	for _, c := range out.ClusterManager.Clusters {
+		if c.Name == cluster.Name {
+			found = c
+		}
+	}
+	if found == nil || found.Type != "sds" || found.ServiceName != svc.Key(port, nil) || found.LbType != "least_request" {
+		t.Errorf("Generate() => got cluster %#v, want an sds cluster with the destination policy", found)
+	}
+}
+
+func TestGenerateHTTPSWithoutAddress(t *testing.T) {
+	ctl := memory.NewController(model.IstioConfig)
+	registry := &model.IstioRegistry{ConfigRegistry: ctl}
+	port := &model.Port{Name: "https", Port: 443, Protocol: model.ProtocolHTTPS}
+	for _, hostname := range []string{"web.default.svc.cluster.local", "api.default.svc.cluster.local"} {
+		if err := ctl.AddService(&model.Service{Hostname: hostname, Ports: model.PortList{port}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	external := &model.Service{
+		Hostname: "secure.example.com",
+		Ports:    model.PortList{port},
+		External: &model.External{LogicalDNS: true},
+	}
+	if err := ctl.AddService(external); err != nil {
+		t.Fatal(err)
+	}
+
+	// without SNI routing, the services cannot be told apart on the port
+	out := Generate(nil, ctl.Services(), registry, testMesh)
+
+	for _, listener := range out.Listeners {
+		if listener.Port == port.Port {
+			t.Errorf("Generate() => got listener %#v, want none on port %d", listener, port.Port)
+		}
+	}
+}
+
+func TestGenerateExternalService(t *testing.T) {
+	ctl := makeTestController(t)
+	registry := &model.IstioRegistry{ConfigRegistry: ctl}
+	external := &model.Service{
+		Hostname: "api.example.com",
+		Ports:    model.PortList{testHTTPPort},
+		External: &model.External{LogicalDNS: true, TLSOrigination: true},
+	}
+	rule := &config.RouteRule{
+		Destination: external.Hostname,
+		Match: &config.MatchCondition{
+			Http: map[string]*config.StringMatch{
+				HeaderURI: {MatchType: &config.StringMatch_Prefix{Prefix: "/v1"}},
+			},
+		},
+		Route: []*config.DestinationWeight{{Weight: 100}},
+	}
+	if _, err := ctl.Post(model.Key{Kind: model.RouteRule, Name: "api", Namespace: "default"}, rule); err != nil {
+		t.Fatal(err)
+	}
+	policy := &config.Destination{
+		Destination: external.Hostname,
+		LoadBalancing: &config.LoadBalancing{
+			LbPolicy: &config.LoadBalancing_Name{Name: config.LoadBalancing_RANDOM},
+		},
+	}
+	if _, err := ctl.Post(model.Key{Kind: model.Destination, Name: "api", Namespace: "default"}, policy); err != nil {
+		t.Fatal(err)
+	}
+
+	out := Generate(nil, append(ctl.Services(), external), registry, testMesh)
+
+	var host *VirtualHost
+	for _, listener := range out.Listeners {
+		if listener.Port != testHTTPPort.Port {
+			continue
+		}
+		for _, vhost := range listener.Filters[0].Config.(*NetworkFilterConfig).RouteConfig.VirtualHosts {
+			if vhost.Name == external.Key(testHTTPPort, nil) {
+				host = vhost
+			}
+		}
+	}
+	if host == nil || len(host.Routes) != 2 || host.Routes[0].Prefix != "/v1" {
+		t.Fatalf("Generate() => got virtual host %#v, want the route rule and the default route", host)
+	}
+
+	name := buildOutboundCluster(external.Hostname, testHTTPPort, nil).Name
+	var cluster *Cluster
+	for _, c := range out.ClusterManager.Clusters {
+		if c.Name == name {
+			cluster = c
+		}
+	}
+	want := &Cluster{
+		Type:       "logical_dns",
+		LbType:     "random",
+		Hosts:      []Host{{URL: "tcp://api.example.com:80"}},
+		SSLContext: &SSLContext{SNI: external.Hostname},
+	}
+	if cluster == nil || cluster.Type != want.Type || cluster.LbType != want.LbType || cluster.ServiceName != "" ||
+		!reflect.DeepEqual(cluster.Hosts, want.Hosts) || !reflect.DeepEqual(cluster.SSLContext, want.SSLContext) {
+		t.Errorf("Generate() => got cluster %#v, want %#v", cluster, want)
+	}
+}
+
+func TestGenerateEgress(t *testing.T) {
+	ctl := makeTestController(t)
+	registry := &model.IstioRegistry{ConfigRegistry: ctl}
+	external := &model.Service{
+		Hostname: "api.example.com",
+		Ports:    model.PortList{testHTTPPort},
+		External: &model.External{},
+	}
+	policy := &config.Destination{
+		Destination: external.Hostname,
+		HttpTimeout: &config.HTTPTimeout{
+			TimeoutPolicy: &config.HTTPTimeout_SimpleTimeout{
+				SimpleTimeout: &config.HTTPTimeout_SimpleTimeoutPolicy{TimeoutSeconds: 1.5},
+			},
+		},
+		HttpRetry: &config.HTTPRetry{
+			RetryPolicy: &config.HTTPRetry_SimpleRetry{
+				SimpleRetry: &config.HTTPRetry_SimpleRetryPolicy{Attempts: 3},
+			},
+		},
+		CircuitBreaker: &config.CircuitBreaker{
+			CbPolicy: &config.CircuitBreaker_SimpleCb{
+				SimpleCb: &config.CircuitBreaker_SimpleCircuitBreakerPolicy{
+					MaxConnections:        10,
+					HttpConsecutiveErrors: 5,
+					HttpDetectionInterval: 10,
+					SleepWindow:           "30s",
+				},
+			},
+		},
+	}
+	if _, err := ctl.Post(model.Key{Kind: model.Destination, Name: "api", Namespace: "default"}, policy); err != nil {
+		t.Fatal(err)
+	}
+
+	out := GenerateEgress(append(ctl.Services(), external), registry, testMesh)
+
+	if len(out.Listeners) != 1 || out.Listeners[0].Port != EgressPort || !out.Listeners[0].BindToPort {
+		t.Fatalf("GenerateEgress() => got listeners %#v, want the egress port", out.Listeners)
+	}
+	vhosts := out.Listeners[0].Filters[0].Config.(*NetworkFilterConfig).RouteConfig.VirtualHosts
+	if len(vhosts) != 1 || !reflect.DeepEqual(vhosts[0].Domains, []string{"api.example.com:80", "api.example.com"}) {
+		t.Fatalf("GenerateEgress() => got virtual hosts %#v, want only the external service", vhosts)
+	}
+	route := vhosts[0].Routes[0]
+	retry := &RetryPolicy{Policy: DefaultRetryOn, NumRetries: 3}
+	if route.TimeoutMS != 1500 || !reflect.DeepEqual(route.RetryPolicy, retry) {
+		t.Errorf("GenerateEgress() => got route %#v, want the timeout and retry policies", route)
+	}
+
+	if len(out.ClusterManager.Clusters) != 1 {
+		t.Fatalf("GenerateEgress() => got clusters %#v, want one", out.ClusterManager.Clusters)
+	}
+	cluster := out.ClusterManager.Clusters[0]
+	if cluster.Type != "strict_dns" || !reflect.DeepEqual(cluster.Hosts, []Host{{URL: "tcp://api.example.com:80"}}) {
+		t.Errorf("GenerateEgress() => got cluster %#v, want a DNS cluster", cluster)
+	}
+	cb := &CircuitBreaker{Default: DefaultCBPriority{MaxConnections: 10}}
+	if !reflect.DeepEqual(cluster.CircuitBreaker, cb) {
+		t.Errorf("GenerateEgress() => got circuit breaker %#v, want %#v", cluster.CircuitBreaker, cb)
+	}
+	want := &OutlierDetection{ConsecutiveError: 5, IntervalMS: 10000, BaseEjectionTimeMS: 30000}
+	if !reflect.DeepEqual(cluster.OutlierDetection, want) {
+		t.Errorf("GenerateEgress() => got outlier detection %#v, want %#v", cluster.OutlierDetection, want)
+	}
+}
+
+func TestGenerateZoneAwareRouting(t *testing.T) {
+	ctl := makeTestController(t)
+	registry := &model.IstioRegistry{ConfigRegistry: ctl}
+	policy := &config.Destination{Destination: testHostname, ZoneAwareRouting: true}
+
+	local := buildOutboundCluster(testHostname, testHTTPPort, nil).Name
+	instances := ctl.HostInstances(map[string]bool{"10.0.0.1": true})
+	out := Generate(instances, ctl.Services(), registry, testMesh)
+	if out.ClusterManager.LocalClusterName != "" || out.ServiceZone != "" {
+		t.Errorf("Generate() => got local cluster %q in zone %q without a policy",
+			out.ClusterManager.LocalClusterName, out.ServiceZone)
+	}
+
+	if _, err := ctl.Post(model.Key{Kind: model.Destination, Name: "zone", Namespace: "default"}, policy); err != nil {
+		t.Fatal(err)
+	}
+	out = Generate(instances, ctl.Services(), registry, testMesh)
+	if out.ClusterManager.LocalClusterName != local || out.ServiceZone != testZone {
+		t.Errorf("Generate() => got local cluster %q in zone %q, want %q in zone %q",
+			out.ClusterManager.LocalClusterName, out.ServiceZone, local, testZone)
+	}
+
+	// the instances without a zone cannot turn on zone aware routing
+	out = Generate(ctl.HostInstances(map[string]bool{"10.0.0.2": true}), ctl.Services(), registry, testMesh)
+	if out.ClusterManager.LocalClusterName != "" {
+		t.Errorf("Generate() => got local cluster %q without a zone", out.ClusterManager.LocalClusterName)
+	}
+}
@@ -430,7 +430,7 @@ func addRule(ruleConfig []byte, kind string, name string, namespace string) erro
 		return fmt.Errorf("Cannot parse proto message from JSON: %v", err)
 	}
 
-	err = istioClient.Put(model.Key{
+	_, err = istioClient.Post(model.Key{
 		Kind:      kind,
 		Name:      name,
 		Namespace: namespace,
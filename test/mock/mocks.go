@@ -75,29 +75,72 @@ func CheckMapInvariant(r model.ConfigRegistry, t *testing.T, namespace string, n
 		elts[i] = Make(i)
 	}
 
-	// put all elements
+	// create all elements
+	revs := make(map[int]string, 0)
 	for i, elt := range elts {
-		if err := r.Put(keys[i], elt); err != nil {
+		rev, err := r.Post(keys[i], elt)
+		if err != nil {
 			t.Error(err)
 		}
+		revs[i] = rev
 	}
 
-	// check that elements are stored
+	// check that elements are stored with their revisions
 	for i, elt := range elts {
-		if v1, ok := r.Get(keys[i]); !ok || !reflect.DeepEqual(v1, elt) {
-			t.Errorf("Wanted %v, got %v", elt, v1)
+		if v1, ok, rev := r.Get(keys[i]); !ok || !reflect.DeepEqual(v1, elt) || rev != revs[i] {
+			t.Errorf("Wanted %v at revision %q, got %v at revision %q", elt, revs[i], v1, rev)
 		}
 	}
 
 	// check for missing element
-	if _, ok := r.Get(model.Key{
+	missing := model.Key{
 		Kind:      Kind,
 		Name:      Name,
 		Namespace: namespace,
-	}); ok {
+	}
+	if _, ok, _ := r.Get(missing); ok {
 		t.Error("Unexpected configuration object found")
 	}
 
+	// creating an existing element fails
+	if _, err := r.Post(keys[0], elts[0]); err == nil {
+		t.Error("Post(existing) => got no error")
+	} else if _, ok := err.(*model.AlreadyExistsError); !ok {
+		t.Errorf("Post(existing) => got %v, want AlreadyExistsError", err)
+	}
+
+	// updating a missing element fails
+	if _, err := r.Put(missing, elts[0], revs[0]); err == nil {
+		t.Error("Put(missing) => got no error")
+	} else if _, ok := err.(*model.NotFoundError); !ok {
+		t.Errorf("Put(missing) => got %v, want NotFoundError", err)
+	}
+
+	// update all elements at their current revisions
+	stale := make(map[int]string, 0)
+	for i := range elts {
+		stale[i] = revs[i]
+		elts[i] = Make(n + i)
+		rev, err := r.Put(keys[i], elts[i], revs[i])
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if rev == revs[i] {
+			t.Errorf("Put() => got unchanged revision %q", rev)
+		}
+		revs[i] = rev
+	}
+
+	// updating at a stale revision fails
+	if n > 0 {
+		if _, err := r.Put(keys[0], Make(0), stale[0]); err == nil {
+			t.Error("Put(stale revision) => got no error")
+		} else if _, ok := err.(*model.ConflictError); !ok {
+			t.Errorf("Put(stale revision) => got %v, want ConflictError", err)
+		}
+	}
+
 	// list elements
 	l, err := r.List(Kind, namespace)
 	if err != nil {
@@ -106,6 +149,13 @@ func CheckMapInvariant(r model.ConfigRegistry, t *testing.T, namespace string, n
 	if len(l) != n {
 		t.Errorf("Wanted %d element(s), got %d in %v", n, len(l), l)
 	}
+	for _, config := range l {
+		for i := range elts {
+			if config.Key == keys[i] && (config.Revision != revs[i] || !reflect.DeepEqual(config.Content, elts[i])) {
+				t.Errorf("Wanted %v at revision %q, got %v", elts[i], revs[i], config)
+			}
+		}
+	}
 
 	// delete all elements
 	for i := range elts {
@@ -114,6 +164,13 @@ func CheckMapInvariant(r model.ConfigRegistry, t *testing.T, namespace string, n
 		}
 	}
 
+	// deleting a missing element fails
+	if err = r.Delete(missing); err == nil {
+		t.Error("Delete(missing) => got no error")
+	} else if _, ok := err.(*model.NotFoundError); !ok {
+		t.Errorf("Delete(missing) => got %v, want NotFoundError", err)
+	}
+
 	l, err = r.List(Kind, namespace)
 	if err != nil {
 		t.Error(err)